@@ -0,0 +1,4 @@
+# Balancers are imported by ID. Prefix the ID with the organization
+# for balancers outside the organization of the provider.
+terraform import instellar_balancer.example 1
+terraform import instellar_balancer.example upmaru/1
@@ -0,0 +1,4 @@
+# Clusters are imported by ID. Prefix the ID with the organization
+# for clusters outside the organization of the provider.
+terraform import instellar_cluster.example 1
+terraform import instellar_cluster.example upmaru/1
@@ -0,0 +1,4 @@
+# Components are imported by ID. Prefix the ID with the organization
+# for components outside the organization of the provider.
+terraform import instellar_component.example 1
+terraform import instellar_component.example upmaru/1
@@ -0,0 +1,4 @@
+# Nodes are imported by ID. Prefix the ID with the organization
+# for nodes outside the organization of the provider.
+terraform import instellar_node.example 1
+terraform import instellar_node.example upmaru/1
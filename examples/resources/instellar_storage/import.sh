@@ -0,0 +1,4 @@
+# Storages are imported by ID. Prefix the ID with the organization
+# for storages outside the organization of the provider.
+terraform import instellar_storage.example 1
+terraform import instellar_storage.example upmaru/1
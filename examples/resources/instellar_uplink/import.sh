@@ -0,0 +1,4 @@
+# Uplinks are imported by ID. Prefix the ID with the organization
+# for uplinks outside the organization of the provider.
+terraform import instellar_uplink.example 1
+terraform import instellar_uplink.example upmaru/1
@@ -4,17 +4,23 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"time"
 
 	// instellar client = instc.
 	instc "github.com/upmaru/instellar-go"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+	"github.com/upmaru/terraform-provider-instellar/internal/operation"
+	"github.com/upmaru/terraform-provider-instellar/internal/organization"
+	"github.com/upmaru/terraform-provider-instellar/internal/timestamps"
+	"github.com/upmaru/terraform-provider-instellar/internal/wait"
 )
 
 var (
@@ -23,28 +29,47 @@ var (
 	_ resource.ResourceWithImportState = &balancerResource{}
 )
 
+// states are the states Create tells apart while waiting for a balancer, the
+// API moves it to active as soon as it is created.
+var states = wait.States{Ready: []string{"active"}, Failed: wait.FailedStates}
+
+// apiAttributes maps the fields of API validation errors to the attributes
+// they come from.
+var apiAttributes = map[string]path.Path{
+	"name":       path.Root("name"),
+	"address":    path.Root("address"),
+	"cluster_id": path.Root("cluster_id"),
+}
+
+// kind describes balancers to the steps every resource shares.
+var kind = operation.Kind{Name: "balancer", Attributes: apiAttributes, States: states}
+
 func NewBalancerResource() resource.Resource {
 	return &balancerResource{}
 }
 
 type balancerResource struct {
-	client *instc.Client
+	client *apiclient.Client
 }
 
 type balancerResourceModel struct {
-	ID           types.String `tfsdk:"id"`
-	Name         types.String `tfsdk:"name"`
-	Address      types.String `tfsdk:"address"`
-	CurrentState types.String `tfsdk:"current_state"`
-	ClusterID    types.String `tfsdk:"cluster_id"`
-	LastUpdated  types.String `tfsdk:"last_updated"`
+	ID           types.String   `tfsdk:"id"`
+	Name         types.String   `tfsdk:"name"`
+	Address      types.String   `tfsdk:"address"`
+	CurrentState types.String   `tfsdk:"current_state"`
+	ClusterID    types.String   `tfsdk:"cluster_id"`
+	Organization types.String   `tfsdk:"organization"`
+	Timeouts     timeouts.Value `tfsdk:"timeouts"`
+	CreatedAt    types.String   `tfsdk:"created_at"`
+	UpdatedAt    types.String   `tfsdk:"updated_at"`
+	LastUpdated  types.String   `tfsdk:"last_updated"`
 }
 
 func (r *balancerResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_balancer"
 }
 
-func (r *balancerResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *balancerResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Balancer registers the load balancer address from your infrastructure load balancer and tells OpsMaru to use the load balancer address for communicating with the cluster.",
 		Attributes: map[string]schema.Attribute{
@@ -70,11 +95,17 @@ func (r *balancerResource) Schema(_ context.Context, _ resource.SchemaRequest, r
 			"cluster_id": schema.StringAttribute{
 				Description: "Which cluster does balancer belong to",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
-			"last_updated": schema.StringAttribute{
-				Description: "Balancer Last Updated",
-				Computed:    true,
-			},
+			"organization": organization.ResourceAttribute(),
+			"created_at":   timestamps.CreatedAtAttribute(),
+			"updated_at":   timestamps.UpdatedAtAttribute(),
+			"last_updated": timestamps.LastUpdatedAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{Create: true, Update: true, Delete: true}),
 		},
 	}
 }
@@ -84,13 +115,13 @@ func (r *balancerResource) Configure(_ context.Context, req resource.ConfigureRe
 		return
 	}
 
-	client, ok := req.ProviderData.(*instc.Client)
+	client, ok := req.ProviderData.(*apiclient.Client)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
 			fmt.Sprintf(
-				"Expected *instc.Client, got: %T. Please report this issue to the provider developers.",
+				"Expected *apiclient.Client, got: %T. Please report this issue to the provider developers.",
 				req.ProviderData,
 			),
 		)
@@ -110,25 +141,44 @@ func (r *balancerResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Create, plan.Timeouts.Create, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	balancerParams := instc.BalancerParams{
 		Name:    plan.Name.ValueString(),
 		Address: plan.Address.ValueString(),
 	}
 
-	balancer, err := r.client.CreateBalancer(plan.ClusterID.ValueString(), balancerParams)
+	balancer, err := r.client.WithContext(ctx).CreateBalancer(plan.ClusterID.ValueString(), balancerParams)
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Failed to create balancer",
-			"Could not create balancer, unexpected error: "+err.Error(),
-		)
+	if kind.Failed(&resp.Diagnostics, operation.Create, err) {
 		return
 	}
 
 	plan.ID = types.StringValue(strconv.Itoa(balancer.Data.Attributes.ID))
 	plan.CurrentState = types.StringValue(balancer.Data.Attributes.CurrentState)
 	plan.ClusterID = types.StringValue(strconv.Itoa(balancer.Data.Attributes.ClusterID))
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	var reported apiclient.Reported
+
+	read := func(ctx context.Context) (string, error) {
+		balancer, err := r.client.GetBalancer(apiclient.WithoutCache(ctx), plan.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		reported = balancer.Reported
+
+		return balancer.Data.Attributes.CurrentState, nil
+	}
+
+	kind.AwaitReady(ctx, &resp.Diagnostics, plan.ID.ValueString(), read, &plan.CurrentState)
+
+	timestamps.Set(ctx, reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -146,12 +196,12 @@ func (r *balancerResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
-	balancer, err := r.client.GetBalancer(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading uplink",
-			"Could not read uplink id "+state.ID.ValueString()+": "+err.Error(),
-		)
+	ctx = apiclient.WithOrganization(ctx, state.Organization.ValueString())
+
+	balancer, err := r.client.GetBalancer(ctx, state.ID.ValueString())
+
+	if kind.Refresh(ctx, resp, state.ID.ValueString(), err) {
+		return
 	}
 
 	state.Name = types.StringValue(balancer.Data.Attributes.Name)
@@ -159,6 +209,8 @@ func (r *balancerResource) Read(ctx context.Context, req resource.ReadRequest, r
 	state.CurrentState = types.StringValue(balancer.Data.Attributes.CurrentState)
 	state.ClusterID = types.StringValue(strconv.Itoa(balancer.Data.Attributes.ClusterID))
 
+	timestamps.Refresh(ctx, balancer.Reported, &state.CreatedAt, &state.UpdatedAt, &state.LastUpdated)
+
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -174,28 +226,27 @@ func (r *balancerResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Update, plan.Timeouts.Update, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	balancerParams := instc.BalancerParams{
 		Name:    plan.Name.ValueString(),
 		Address: plan.Address.ValueString(),
 	}
 
-	_, err := r.client.UpdateBalancer(plan.ID.ValueString(), balancerParams)
+	_, err := r.client.WithContext(ctx).UpdateBalancer(plan.ID.ValueString(), balancerParams)
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error updating balancer",
-			"Could not update balancer, unexpected error: "+err.Error(),
-		)
+	if kind.Failed(&resp.Diagnostics, operation.Update, err) {
 		return
 	}
 
-	balancer, err := r.client.GetBalancer(plan.ID.ValueString())
+	balancer, err := r.client.GetBalancer(ctx, plan.ID.ValueString())
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading balancer",
-			"Could not read balancer ID "+plan.ID.ValueString()+": "+err.Error(),
-		)
+	if kind.ReadFailed(&resp.Diagnostics, plan.ID.ValueString(), err) {
 		return
 	}
 
@@ -203,7 +254,8 @@ func (r *balancerResource) Update(ctx context.Context, req resource.UpdateReques
 	plan.Address = types.StringValue(balancer.Data.Attributes.Address)
 	plan.CurrentState = types.StringValue(balancer.Data.Attributes.CurrentState)
 	plan.ClusterID = types.StringValue(strconv.Itoa(balancer.Data.Attributes.ClusterID))
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	timestamps.Set(ctx, balancer.Reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -220,16 +272,33 @@ func (r *balancerResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
-	_, err := r.client.DeleteBalancer(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error deleting balancer",
-			"Cloud not delete balancer, unexpected error: "+err.Error(),
-		)
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Delete, state.Timeouts.Delete, state.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	_, err := r.client.WithContext(ctx).DeleteBalancer(state.ID.ValueString())
+
+	if apiclient.IsNotFound(err) {
+		return
+	}
+
+	if kind.Failed(&resp.Diagnostics, operation.Delete, err) {
+		return
+	}
+
+	kind.AwaitDeletion(ctx, &resp.Diagnostics, state.ID.ValueString(), func(ctx context.Context) (string, error) {
+		balancer, err := r.client.GetBalancer(apiclient.WithoutCache(ctx), state.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		return balancer.Data.Attributes.CurrentState, nil
+	})
 }
 
 func (r *balancerResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	organization.ImportState(ctx, req, resp)
 }
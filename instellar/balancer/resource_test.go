@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/upmaru/terraform-provider-instellar/internal/acceptance"
+	"github.com/upmaru/terraform-provider-instellar/internal/acceptancetest"
 )
 
 func TestAccBalancerResource(t *testing.T) {
@@ -15,8 +17,7 @@ func TestAccBalancerResource(t *testing.T) {
 	clusterNameSegments := strings.Split(clusterUUID.String(), "-")
 	clusterNameSlug := strings.Join([]string{clusterNameSegments[0], clusterNameSegments[1]}, "-")
 
-	resource.ParallelTest(t, resource.TestCase{
-		ProtoV6ProviderFactories: acceptance.TestAccProtoV6ProviderFactories,
+	acceptance.ParallelTest(t, resource.TestCase{
 		Steps: []resource.TestStep{
 			{
 				Config: buildConfig(clusterNameSlug),
@@ -27,14 +28,16 @@ func TestAccBalancerResource(t *testing.T) {
 					resource.TestCheckResourceAttr("instellar_balancer.test", "current_state", "active"),
 					// Dynamic values
 					resource.TestCheckResourceAttrSet("instellar_balancer.test", "id"),
-					resource.TestCheckResourceAttrSet("instellar_balancer.test", "last_updated"),
+					resource.TestCheckResourceAttrSet("instellar_balancer.test", "created_at"),
+					resource.TestCheckResourceAttrSet("instellar_balancer.test", "updated_at"),
+					resource.TestCheckResourceAttrPair("instellar_balancer.test", "last_updated", "instellar_balancer.test", "updated_at"),
 				),
 			},
 			{
 				ResourceName:            "instellar_balancer.test",
 				ImportState:             true,
 				ImportStateVerify:       true,
-				ImportStateVerifyIgnore: []string{"last_updated", "current_state"},
+				ImportStateVerifyIgnore: []string{"current_state"},
 			},
 		},
 	})
@@ -57,3 +60,40 @@ func buildConfig(clusterName string) string {
 		}
 	`, clusterName)
 }
+
+func TestBalancerResourcePlanReplacesBalancer(t *testing.T) {
+	prior := map[string]tftypes.Value{
+		"id":            tftypes.NewValue(tftypes.String, "1"),
+		"name":          tftypes.NewValue(tftypes.String, "balancer"),
+		"address":       tftypes.NewValue(tftypes.String, "some.address.com"),
+		"current_state": tftypes.NewValue(tftypes.String, "active"),
+		"cluster_id":    tftypes.NewValue(tftypes.String, "1"),
+		"last_updated":  tftypes.NewValue(tftypes.String, "2024-01-01T00:00:00Z"),
+	}
+
+	testCases := map[string]struct {
+		value    tftypes.Value
+		replaced bool
+	}{
+		"cluster_id": {value: tftypes.NewValue(tftypes.String, "2"), replaced: true},
+		"name":       {value: tftypes.NewValue(tftypes.String, "another-balancer"), replaced: false},
+		"address":    {value: tftypes.NewValue(tftypes.String, "another.address.com"), replaced: false},
+	}
+
+	for attribute, testCase := range testCases {
+		t.Run(attribute, func(t *testing.T) {
+			config := map[string]tftypes.Value{
+				"name":       prior["name"],
+				"address":    prior["address"],
+				"cluster_id": prior["cluster_id"],
+			}
+			config[attribute] = testCase.value
+
+			resp := acceptancetest.PlanUpdate(t, nil, "instellar_balancer", prior, config)
+
+			if replaced := acceptancetest.RequiresReplace(resp, attribute); replaced != testCase.replaced {
+				t.Fatalf("expected replacement %t for %s, got %v", testCase.replaced, attribute, resp.RequiresReplace)
+			}
+		})
+	}
+}
@@ -5,54 +5,87 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
-	"time"
 
 	// instellar client = instc.
 	instc "github.com/upmaru/instellar-go"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+	"github.com/upmaru/terraform-provider-instellar/internal/defaults"
+	"github.com/upmaru/terraform-provider-instellar/internal/operation"
+	"github.com/upmaru/terraform-provider-instellar/internal/organization"
+	"github.com/upmaru/terraform-provider-instellar/internal/timestamps"
+	"github.com/upmaru/terraform-provider-instellar/internal/wait"
 )
 
 var (
 	_ resource.Resource                = &clusterResource{}
 	_ resource.ResourceWithConfigure   = &clusterResource{}
 	_ resource.ResourceWithImportState = &clusterResource{}
+	_ resource.ResourceWithModifyPlan  = &clusterResource{}
 )
 
+// states are the states Create tells apart while waiting for a cluster, the
+// API moves it from connecting through syncing to healthy.
+var states = wait.States{Ready: []string{"healthy"}, Failed: wait.FailedStates}
+
+// apiAttributes maps the fields of API validation errors to the attributes
+// they come from.
+var apiAttributes = map[string]path.Path{
+	"name":                             path.Root("name"),
+	"slug":                             path.Root("name"),
+	"provider":                         path.Root("provider_name"),
+	"region":                           path.Root("region"),
+	"credential_endpoint":              path.Root("endpoint"),
+	"credential_password":              path.Root("password_token"),
+	"credential_password_confirmation": path.Root("password_token"),
+	"insterra_component_id":            path.Root("insterra_component_id"),
+}
+
+// kind describes clusters to the steps every resource shares.
+var kind = operation.Kind{Name: "cluster", Attributes: apiAttributes, States: states}
+
 func NewClusterResource() resource.Resource {
 	return &clusterResource{}
 }
 
 type clusterResource struct {
-	client *instc.Client
+	client *apiclient.Client
 }
 
 type clusterResourceModel struct {
-	ID                  types.String `tfsdk:"id"`
-	Name                types.String `tfsdk:"name"`
-	Slug                types.String `tfsdk:"slug"`
-	CurrentState        types.String `tfsdk:"current_state"`
-	ProviderName        types.String `tfsdk:"provider_name"`
-	Region              types.String `tfsdk:"region"`
-	Endpoint            types.String `tfsdk:"endpoint"`
-	PasswordToken       types.String `tfsdk:"password_token"`
-	InsterraComponentID types.Int64  `tfsdk:"insterra_component_id"`
-	LastUpdated         types.String `tfsdk:"last_updated"`
+	ID                  types.String   `tfsdk:"id"`
+	Name                types.String   `tfsdk:"name"`
+	Slug                types.String   `tfsdk:"slug"`
+	CurrentState        types.String   `tfsdk:"current_state"`
+	ProviderName        types.String   `tfsdk:"provider_name"`
+	Region              types.String   `tfsdk:"region"`
+	Endpoint            types.String   `tfsdk:"endpoint"`
+	PasswordToken       types.String   `tfsdk:"password_token"`
+	InsterraComponentID types.Int64    `tfsdk:"insterra_component_id"`
+	Organization        types.String   `tfsdk:"organization"`
+	Timeouts            timeouts.Value `tfsdk:"timeouts"`
+	CreatedAt           types.String   `tfsdk:"created_at"`
+	UpdatedAt           types.String   `tfsdk:"updated_at"`
+	LastUpdated         types.String   `tfsdk:"last_updated"`
 }
 
 func (r *clusterResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_cluster"
 }
 
-func (r *clusterResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *clusterResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Clusters are the foundation compute layer that run your application containers.",
 		Attributes: map[string]schema.Attribute{
@@ -66,6 +99,9 @@ func (r *clusterResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 			"name": schema.StringAttribute{
 				Description: "Name assigned by the user",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 				Validators: []validator.String{
 					stringvalidator.LengthBetween(3, 48),
 					stringvalidator.RegexMatches(
@@ -83,15 +119,17 @@ func (r *clusterResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 				Computed:    true,
 			},
 			"provider_name": schema.StringAttribute{
-				Description: "Provider of the infrastructure",
-				Required:    true,
+				Description: "Provider of the infrastructure, defaults to provider_name of the provider defaults block",
+				Optional:    true,
+				Computed:    true,
 				Validators: []validator.String{
 					stringvalidator.OneOf([]string{"aws", "hcloud", "digitalocean", "google", "azurerm"}...),
 				},
 			},
 			"region": schema.StringAttribute{
-				Description: "Region of the cluster",
-				Required:    true,
+				Description: "Region of the cluster, defaults to region of the provider defaults block",
+				Optional:    true,
+				Computed:    true,
 			},
 			"endpoint": schema.StringAttribute{
 				Description: "Endpoint for cluster",
@@ -105,11 +143,19 @@ func (r *clusterResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 			"insterra_component_id": schema.Int64Attribute{
 				Description: "Reference to insterra component",
 				Optional:    true,
-			},
-			"last_updated": schema.StringAttribute{
-				Description: "Timestamp of the terraform update",
 				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+					int64planmodifier.RequiresReplaceIfConfigured(),
+				},
 			},
+			"organization": organization.ResourceAttribute(),
+			"created_at":   timestamps.CreatedAtAttribute(),
+			"updated_at":   timestamps.UpdatedAtAttribute(),
+			"last_updated": timestamps.LastUpdatedAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{Create: true, Update: true, Delete: true}),
 		},
 	}
 }
@@ -119,13 +165,13 @@ func (r *clusterResource) Configure(_ context.Context, req resource.ConfigureReq
 		return
 	}
 
-	client, ok := req.ProviderData.(*instc.Client)
+	client, ok := req.ProviderData.(*apiclient.Client)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
 			fmt.Sprintf(
-				"Expected *instc.Client, got: %T. Please report this issue to the provider developers.",
+				"Expected *apiclient.Client, got: %T. Please report this issue to the provider developers.",
 				req.ProviderData,
 			),
 		)
@@ -135,6 +181,19 @@ func (r *clusterResource) Configure(_ context.Context, req resource.ConfigureReq
 	r.client = client
 }
 
+func (r *clusterResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	providerDefaults := r.client.Defaults()
+
+	defaults.String(ctx, req, resp, path.Root("provider_name"), providerDefaults.ProviderName)
+	defaults.RequiresReplace(ctx, req, resp, path.Root("provider_name"))
+	defaults.String(ctx, req, resp, path.Root("region"), providerDefaults.Region)
+	defaults.RequiresReplace(ctx, req, resp, path.Root("region"))
+}
+
 func (r *clusterResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var plan clusterResourceModel
 	diags := req.Plan.Get(ctx, &plan)
@@ -145,6 +204,13 @@ func (r *clusterResource) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Create, plan.Timeouts.Create, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	clusterParams := instc.ClusterParams{
 		Name:                           plan.Name.ValueString(),
 		Provider:                       plan.ProviderName.ValueString(),
@@ -155,13 +221,9 @@ func (r *clusterResource) Create(ctx context.Context, req resource.CreateRequest
 		InsterraComponentID:            int(plan.InsterraComponentID.ValueInt64()),
 	}
 
-	cluster, err := r.client.CreateCluster(clusterParams)
+	cluster, err := r.client.WithContext(ctx).CreateCluster(clusterParams)
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error creating instellar cluster",
-			"Cloud not create cluster, unexpected error: "+err.Error(),
-		)
+	if kind.Failed(&resp.Diagnostics, operation.Create, err) {
 		return
 	}
 
@@ -170,7 +232,33 @@ func (r *clusterResource) Create(ctx context.Context, req resource.CreateRequest
 	plan.Slug = types.StringValue(cluster.Data.Attributes.Slug)
 	plan.Region = types.StringValue(cluster.Data.Attributes.Region)
 	plan.CurrentState = types.StringValue(cluster.Data.Attributes.CurrentState)
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	var (
+		reported            apiclient.Reported
+		insterraComponentID int
+	)
+
+	read := func(ctx context.Context) (string, error) {
+		cluster, err := r.client.GetCluster(apiclient.WithoutCache(ctx), plan.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		reported = cluster.Reported
+		insterraComponentID = cluster.InsterraComponentID
+
+		return cluster.Data.Attributes.CurrentState, nil
+	}
+
+	kind.AwaitReady(ctx, &resp.Diagnostics, plan.ID.ValueString(), read, &plan.CurrentState)
+
+	if insterraComponentID != 0 {
+		plan.InsterraComponentID = types.Int64Value(int64(insterraComponentID))
+	} else if plan.InsterraComponentID.IsUnknown() {
+		plan.InsterraComponentID = types.Int64Null()
+	}
+
+	timestamps.Set(ctx, reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -188,12 +276,11 @@ func (r *clusterResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
-	cluster, err := r.client.GetCluster(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading instellar cluster",
-			"Cloud not read cluster id "+state.ID.ValueString()+": "+err.Error(),
-		)
+	ctx = apiclient.WithOrganization(ctx, state.Organization.ValueString())
+
+	cluster, err := r.client.GetCluster(ctx, state.ID.ValueString())
+
+	if kind.Refresh(ctx, resp, state.ID.ValueString(), err) {
 		return
 	}
 
@@ -204,6 +291,12 @@ func (r *clusterResource) Read(ctx context.Context, req resource.ReadRequest, re
 	state.Region = types.StringValue(cluster.Data.Attributes.Region)
 	state.CurrentState = types.StringValue(cluster.Data.Attributes.CurrentState)
 
+	if cluster.InsterraComponentID != 0 {
+		state.InsterraComponentID = types.Int64Value(int64(cluster.InsterraComponentID))
+	}
+
+	timestamps.Refresh(ctx, cluster.Reported, &state.CreatedAt, &state.UpdatedAt, &state.LastUpdated)
+
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -219,33 +312,42 @@ func (r *clusterResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Update, plan.Timeouts.Update, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	clusterParams := instc.ClusterParams{
-		CredentialEndpoint: plan.Endpoint.ValueString(),
+		CredentialEndpoint:             plan.Endpoint.ValueString(),
+		CredentialPassword:             plan.PasswordToken.ValueString(),
+		CredentialPasswordConfirmation: plan.PasswordToken.ValueString(),
 	}
 
-	_, err := r.client.UpdateCluster(plan.ID.ValueString(), clusterParams)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error updating instellar cluster",
-			"Could not update cluster, unexpected error: "+err.Error(),
-		)
+	_, err := r.client.WithContext(ctx).UpdateCluster(plan.ID.ValueString(), clusterParams)
+
+	if kind.Failed(&resp.Diagnostics, operation.Update, err) {
 		return
 	}
 
-	cluster, err := r.client.GetCluster(plan.ID.ValueString())
+	cluster, err := r.client.GetCluster(ctx, plan.ID.ValueString())
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading instellar cluster",
-			"Could not read instellar cluster ID "+plan.ID.ValueString()+": "+err.Error(),
-		)
+	if kind.ReadFailed(&resp.Diagnostics, plan.ID.ValueString(), err) {
 		return
 	}
 
 	plan.Slug = types.StringValue(cluster.Data.Attributes.Slug)
 	plan.Endpoint = types.StringValue(cluster.Data.Attributes.Endpoint)
 	plan.CurrentState = types.StringValue(cluster.Data.Attributes.CurrentState)
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	if cluster.InsterraComponentID != 0 {
+		plan.InsterraComponentID = types.Int64Value(int64(cluster.InsterraComponentID))
+	} else if plan.InsterraComponentID.IsUnknown() {
+		plan.InsterraComponentID = types.Int64Null()
+	}
+
+	timestamps.Set(ctx, cluster.Reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -262,16 +364,33 @@ func (r *clusterResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
-	_, err := r.client.DeleteCluster(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error deleting cluster",
-			"cloud not delete cluster, unexpected error: "+err.Error(),
-		)
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Delete, state.Timeouts.Delete, state.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	_, err := r.client.WithContext(ctx).DeleteCluster(state.ID.ValueString())
+
+	if apiclient.IsNotFound(err) {
+		return
+	}
+
+	if kind.Failed(&resp.Diagnostics, operation.Delete, err) {
+		return
+	}
+
+	kind.AwaitDeletion(ctx, &resp.Diagnostics, state.ID.ValueString(), func(ctx context.Context) (string, error) {
+		cluster, err := r.client.GetCluster(apiclient.WithoutCache(ctx), state.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		return cluster.Data.Attributes.CurrentState, nil
+	})
 }
 
 func (r *clusterResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	organization.ImportState(ctx, req, resp)
 }
@@ -1,13 +1,26 @@
 package cluster_test
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/upmaru/terraform-provider-instellar/instellar/cluster"
 	"github.com/upmaru/terraform-provider-instellar/internal/acceptance"
+	"github.com/upmaru/terraform-provider-instellar/internal/acceptancetest"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+
+	// instellar client = instc.
+	instc "github.com/upmaru/instellar-go"
 )
 
 func TestAccClusterResource(t *testing.T) {
@@ -15,8 +28,7 @@ func TestAccClusterResource(t *testing.T) {
 	clusterNameSegments := strings.Split(clusterUUID.String(), "-")
 	clusterNameSlug := strings.Join([]string{clusterNameSegments[0], clusterNameSegments[1]}, "-")
 
-	resource.ParallelTest(t, resource.TestCase{
-		ProtoV6ProviderFactories: acceptance.TestAccProtoV6ProviderFactories,
+	acceptance.ParallelTest(t, resource.TestCase{
 		Steps: []resource.TestStep{
 			{
 				Config: buildConfig(clusterNameSlug, "127.0.0.1:8443"),
@@ -26,17 +38,19 @@ func TestAccClusterResource(t *testing.T) {
 
 					// Verify computed attribute fields.
 					resource.TestCheckResourceAttr("instellar_cluster.test", "slug", clusterNameSlug),
-					resource.TestCheckResourceAttr("instellar_cluster.test", "current_state", "connecting"),
+					resource.TestCheckResourceAttr("instellar_cluster.test", "current_state", "healthy"),
 					// Verify dynamic vlaues have value set
 					resource.TestCheckResourceAttrSet("instellar_cluster.test", "id"),
-					resource.TestCheckResourceAttrSet("instellar_cluster.test", "last_updated"),
+					resource.TestCheckResourceAttrSet("instellar_cluster.test", "created_at"),
+					resource.TestCheckResourceAttrSet("instellar_cluster.test", "updated_at"),
+					resource.TestCheckResourceAttrPair("instellar_cluster.test", "last_updated", "instellar_cluster.test", "updated_at"),
 				),
 			},
 			{
 				ResourceName:            "instellar_cluster.test",
 				ImportState:             true,
 				ImportStateVerify:       true,
-				ImportStateVerifyIgnore: []string{"last_updated", "password_token", "current_state", "insterra_component_id"},
+				ImportStateVerifyIgnore: []string{"password_token", "current_state", "insterra_component_id"},
 			},
 			{
 				Config: buildConfig(clusterNameSlug, "38.43.56.78:8443"),
@@ -47,7 +61,9 @@ func TestAccClusterResource(t *testing.T) {
 					resource.TestCheckResourceAttr("instellar_cluster.test", "slug", clusterNameSlug),
 					// Verify dynamic values have value set
 					resource.TestCheckResourceAttrSet("instellar_cluster.test", "id"),
-					resource.TestCheckResourceAttrSet("instellar_cluster.test", "last_updated"),
+					resource.TestCheckResourceAttrSet("instellar_cluster.test", "created_at"),
+					resource.TestCheckResourceAttrSet("instellar_cluster.test", "updated_at"),
+					resource.TestCheckResourceAttrPair("instellar_cluster.test", "last_updated", "instellar_cluster.test", "updated_at"),
 				),
 			},
 		},
@@ -65,3 +81,222 @@ func buildConfig(clusterNameSlug string, endpoint string) string {
 		}
 	`, clusterNameSlug, endpoint)
 }
+
+func TestAccClusterResourceProviderDefaults(t *testing.T) {
+	clusterUUID := uuid.New()
+	clusterNameSegments := strings.Split(clusterUUID.String(), "-")
+	clusterNameSlug := strings.Join([]string{clusterNameSegments[0], clusterNameSegments[1]}, "-")
+
+	acceptance.ParallelTest(t, resource.TestCase{
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					provider "instellar" {
+						defaults {
+							provider_name = "aws"
+							region = "ap-southeast-1"
+						}
+					}
+
+					resource "instellar_cluster" "test" {
+						name = "%s"
+						endpoint = "127.0.0.1:8443"
+						password_token = "some-password-or-token"
+					}
+				`, clusterNameSlug),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("instellar_cluster.test", "provider_name", "aws"),
+					resource.TestCheckResourceAttr("instellar_cluster.test", "region", "ap-southeast-1"),
+				),
+			},
+		},
+	})
+}
+
+func TestClusterResourceDeleteWaitsForDeletion(t *testing.T) {
+	var deleted, reads int32
+
+	client := acceptancetest.ServerClient(t, apiclient.Options{CacheTTL: time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodDelete:
+			atomic.StoreInt32(&deleted, 1)
+			_, _ = w.Write([]byte(`{"data":{"attributes":{"id":1,"current_state":"deleting"}}}`))
+		case atomic.AddInt32(&reads, 1) == 1:
+			_, _ = w.Write([]byte(`{"data":{"attributes":{"id":1,"current_state":"deleting"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":{"detail":"Not Found"}}`))
+		}
+	})
+
+	resp := acceptancetest.Delete(t, cluster.NewClusterResource(), client, "1")
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	if atomic.LoadInt32(&deleted) != 1 || atomic.LoadInt32(&reads) != 2 {
+		t.Errorf("expected delete to poll until the cluster is gone, got %d reads", atomic.LoadInt32(&reads))
+	}
+}
+
+func TestClusterResourceCreateStopsAtDeletedState(t *testing.T) {
+	var reads int32
+
+	client := acceptancetest.ServerClient(t, apiclient.Options{}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"data":{"attributes":{"id":1,"slug":"pizza","current_state":"connecting"}}}`))
+		default:
+			atomic.AddInt32(&reads, 1)
+			_, _ = w.Write([]byte(`{"data":{"attributes":{"id":1,"slug":"pizza","current_state":"deleted"}}}`))
+		}
+	})
+
+	resp := acceptancetest.Create(t, cluster.NewClusterResource(), client, map[string]tftypes.Value{
+		"name":           tftypes.NewValue(tftypes.String, "pizza"),
+		"provider_name":  tftypes.NewValue(tftypes.String, "aws"),
+		"region":         tftypes.NewValue(tftypes.String, "ap-southeast-1"),
+		"endpoint":       tftypes.NewValue(tftypes.String, "127.0.0.1:8443"),
+		"password_token": tftypes.NewValue(tftypes.String, "some-password-or-token"),
+	})
+
+	if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), `reached state "deleted"`) {
+		t.Fatalf("expected creation to fail on the deleted state, got %v", resp.Diagnostics)
+	}
+
+	if atomic.LoadInt32(&reads) != 1 {
+		t.Errorf("expected a single read, got %d", atomic.LoadInt32(&reads))
+	}
+}
+
+func TestClusterResourceCreateReportsRejectedAttributes(t *testing.T) {
+	client := acceptancetest.MemoryClient(t, apiclient.Options{})
+
+	if _, err := client.WithContext(context.Background()).CreateCluster(instc.ClusterParams{Name: "pizza", Provider: "aws", Region: "ap-southeast-1"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resp := acceptancetest.Create(t, cluster.NewClusterResource(), client, map[string]tftypes.Value{
+		"name":           tftypes.NewValue(tftypes.String, "pizza"),
+		"provider_name":  tftypes.NewValue(tftypes.String, "aws"),
+		"region":         tftypes.NewValue(tftypes.String, "ap-southeast-1"),
+		"endpoint":       tftypes.NewValue(tftypes.String, "127.0.0.1:8443"),
+		"password_token": tftypes.NewValue(tftypes.String, "some-password-or-token"),
+	})
+
+	if resp.Diagnostics.ErrorsCount() != 1 {
+		t.Fatalf("expected a single error, got %v", resp.Diagnostics)
+	}
+
+	attributeDiag, ok := resp.Diagnostics[0].(diag.DiagnosticWithPath)
+	if !ok || !attributeDiag.Path().Equal(path.Root("name")) {
+		t.Fatalf("expected error on name, got %v", resp.Diagnostics[0])
+	}
+
+	if detail := attributeDiag.Detail(); !strings.Contains(detail, "has already been taken") {
+		t.Errorf("expected API message in detail, got %s", detail)
+	}
+}
+
+func TestClusterResourcePlanReplacesCluster(t *testing.T) {
+	prior := map[string]tftypes.Value{
+		"id":                    tftypes.NewValue(tftypes.String, "1"),
+		"name":                  tftypes.NewValue(tftypes.String, "pizza"),
+		"slug":                  tftypes.NewValue(tftypes.String, "pizza"),
+		"current_state":         tftypes.NewValue(tftypes.String, "healthy"),
+		"provider_name":         tftypes.NewValue(tftypes.String, "aws"),
+		"region":                tftypes.NewValue(tftypes.String, "ap-southeast-1"),
+		"endpoint":              tftypes.NewValue(tftypes.String, "127.0.0.1:8443"),
+		"password_token":        tftypes.NewValue(tftypes.String, "some-password-or-token"),
+		"last_updated":          tftypes.NewValue(tftypes.String, "2024-01-01T00:00:00Z"),
+		"insterra_component_id": tftypes.NewValue(tftypes.Number, 7),
+	}
+
+	defaultsBlock := tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"provider_name": tftypes.String,
+		"region":        tftypes.String,
+		"channels":      tftypes.List{ElementType: tftypes.String},
+	}}, map[string]tftypes.Value{
+		"provider_name": tftypes.NewValue(tftypes.String, "aws"),
+		"region":        tftypes.NewValue(tftypes.String, "ap-southeast-1"),
+		"channels":      tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, nil),
+	})
+
+	testCases := map[string]struct {
+		providerConfig map[string]tftypes.Value
+		config         map[string]tftypes.Value
+		replaced       string
+	}{
+		"name": {
+			config:   map[string]tftypes.Value{"name": tftypes.NewValue(tftypes.String, "pasta")},
+			replaced: "name",
+		},
+		"provider_name": {
+			config:   map[string]tftypes.Value{"provider_name": tftypes.NewValue(tftypes.String, "hcloud")},
+			replaced: "provider_name",
+		},
+		"region": {
+			config:   map[string]tftypes.Value{"region": tftypes.NewValue(tftypes.String, "ap-northeast-1")},
+			replaced: "region",
+		},
+		"defaulted region": {
+			providerConfig: map[string]tftypes.Value{
+				"defaults": defaultsBlock,
+			},
+			config: map[string]tftypes.Value{
+				"provider_name": tftypes.NewValue(tftypes.String, nil),
+				"region":        tftypes.NewValue(tftypes.String, "eu-west-1"),
+			},
+			replaced: "region",
+		},
+		"endpoint": {
+			config: map[string]tftypes.Value{"endpoint": tftypes.NewValue(tftypes.String, "38.43.56.78:8443")},
+		},
+		"password_token": {
+			config: map[string]tftypes.Value{"password_token": tftypes.NewValue(tftypes.String, "another-password-or-token")},
+		},
+		"insterra_component_id": {
+			config:   map[string]tftypes.Value{"insterra_component_id": tftypes.NewValue(tftypes.Number, 8)},
+			replaced: "insterra_component_id",
+		},
+		"endpoint with defaults": {
+			providerConfig: map[string]tftypes.Value{
+				"defaults": defaultsBlock,
+			},
+			config: map[string]tftypes.Value{
+				"provider_name": tftypes.NewValue(tftypes.String, nil),
+				"region":        tftypes.NewValue(tftypes.String, nil),
+				"endpoint":      tftypes.NewValue(tftypes.String, "38.43.56.78:8443"),
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			config := map[string]tftypes.Value{
+				"name":                  prior["name"],
+				"provider_name":         prior["provider_name"],
+				"region":                prior["region"],
+				"endpoint":              prior["endpoint"],
+				"password_token":        prior["password_token"],
+				"insterra_component_id": prior["insterra_component_id"],
+			}
+
+			for attribute, value := range testCase.config {
+				config[attribute] = value
+			}
+
+			resp := acceptancetest.PlanUpdate(t, testCase.providerConfig, "instellar_cluster", prior, config)
+
+			if testCase.replaced == "" && len(resp.RequiresReplace) > 0 {
+				t.Fatalf("expected an update in place, got replacement for %v", resp.RequiresReplace)
+			}
+
+			if testCase.replaced != "" && !acceptancetest.RequiresReplace(resp, testCase.replaced) {
+				t.Fatalf("expected replacement for %s, got %v", testCase.replaced, resp.RequiresReplace)
+			}
+		})
+	}
+}
@@ -5,14 +5,15 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
-	"time"
 
 	instc "github.com/upmaru/instellar-go"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
@@ -20,35 +21,75 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+	"github.com/upmaru/terraform-provider-instellar/internal/defaults"
+	"github.com/upmaru/terraform-provider-instellar/internal/operation"
+	"github.com/upmaru/terraform-provider-instellar/internal/organization"
+	"github.com/upmaru/terraform-provider-instellar/internal/timestamps"
+	"github.com/upmaru/terraform-provider-instellar/internal/wait"
 )
 
 var (
 	_ resource.Resource                = &componentResource{}
 	_ resource.ResourceWithConfigure   = &componentResource{}
 	_ resource.ResourceWithImportState = &componentResource{}
+	_ resource.ResourceWithModifyPlan  = &componentResource{}
 )
 
+// states are the states Create tells apart while waiting for a component,
+// the API moves it to active as soon as it is created.
+var states = wait.States{Ready: []string{"active"}, Failed: wait.FailedStates}
+
+// apiAttributes maps the fields of API validation errors to the attributes
+// they come from.
+var apiAttributes = map[string]path.Path{
+	"name":                   path.Root("name"),
+	"slug":                   path.Root("name"),
+	"provider":               path.Root("provider_name"),
+	"version":                path.Root("driver_version"),
+	"driver":                 path.Root("driver"),
+	"channels":               path.Root("channels"),
+	"cluster_ids":            path.Root("cluster_ids"),
+	"insterra_component_id":  path.Root("insterra_component_id"),
+	"credential":             path.Root("credential"),
+	"credential.username":    path.Root("credential").AtName("username"),
+	"credential.password":    path.Root("credential").AtName("password"),
+	"credential.resource":    path.Root("credential").AtName("resource"),
+	"credential.host":        path.Root("credential").AtName("host"),
+	"credential.port":        path.Root("credential").AtName("port"),
+	"credential.certificate": path.Root("credential").AtName("certificate"),
+	"credential.secure":      path.Root("credential").AtName("secure"),
+}
+
+// kind describes components to the steps every resource shares.
+var kind = operation.Kind{Name: "component", Attributes: apiAttributes, States: states}
+
 func NewComponentResource() resource.Resource {
 	return &componentResource{}
 }
 
 type componentResource struct {
-	client *instc.Client
+	client *apiclient.Client
 }
 
 type componentResourceModel struct {
-	ID                  types.String `tfsdk:"id"`
-	Name                types.String `tfsdk:"name"`
-	Slug                types.String `tfsdk:"slug"`
-	DriverVersion       types.String `tfsdk:"driver_version"`
-	CurrentState        types.String `tfsdk:"current_state"`
-	ProviderName        types.String `tfsdk:"provider_name"`
-	Driver              types.String `tfsdk:"driver"`
-	ClusterIDS          types.List   `tfsdk:"cluster_ids"`
-	Channels            types.List   `tfsdk:"channels"`
-	Credential          types.Object `tfsdk:"credential"`
-	InsterraComponentID types.Int64  `tfsdk:"insterra_component_id"`
-	LastUpdated         types.String `tfsdk:"last_updated"`
+	ID                  types.String   `tfsdk:"id"`
+	Name                types.String   `tfsdk:"name"`
+	Slug                types.String   `tfsdk:"slug"`
+	DriverVersion       types.String   `tfsdk:"driver_version"`
+	CurrentState        types.String   `tfsdk:"current_state"`
+	ProviderName        types.String   `tfsdk:"provider_name"`
+	Driver              types.String   `tfsdk:"driver"`
+	ClusterIDS          types.List     `tfsdk:"cluster_ids"`
+	Channels            types.List     `tfsdk:"channels"`
+	Credential          types.Object   `tfsdk:"credential"`
+	InsterraComponentID types.Int64    `tfsdk:"insterra_component_id"`
+	Organization        types.String   `tfsdk:"organization"`
+	Timeouts            timeouts.Value `tfsdk:"timeouts"`
+	CreatedAt           types.String   `tfsdk:"created_at"`
+	UpdatedAt           types.String   `tfsdk:"updated_at"`
+	LastUpdated         types.String   `tfsdk:"last_updated"`
 }
 
 type componentCredentialResourceModel struct {
@@ -65,7 +106,7 @@ func (r *componentResource) Metadata(_ context.Context, req resource.MetadataReq
 	resp.TypeName = req.ProviderTypeName + "_component"
 }
 
-func (r *componentResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *componentResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Components enable you to add things like PostgreSQL, MySQL, Redis or any other 'components' and associate them to a given cluster.",
 		Attributes: map[string]schema.Attribute{
@@ -79,6 +120,9 @@ func (r *componentResource) Schema(_ context.Context, _ resource.SchemaRequest,
 			"name": schema.StringAttribute{
 				Description: "Name of the component assigned by the user",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 				Validators: []validator.String{
 					stringvalidator.LengthBetween(3, 64),
 					stringvalidator.RegexMatches(
@@ -100,8 +144,9 @@ func (r *componentResource) Schema(_ context.Context, _ resource.SchemaRequest,
 				Required:    true,
 			},
 			"provider_name": schema.StringAttribute{
-				Description: "Provider of the infrastructure",
-				Required:    true,
+				Description: "Provider of the infrastructure, defaults to provider_name of the provider defaults block",
+				Optional:    true,
+				Computed:    true,
 				Validators: []validator.String{
 					stringvalidator.OneOf([]string{"aws", "hcloud", "digitalocean", "google", "azurerm"}...),
 				},
@@ -109,6 +154,9 @@ func (r *componentResource) Schema(_ context.Context, _ resource.SchemaRequest,
 			"driver": schema.StringAttribute{
 				Description: "Driver of the component",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"cluster_ids": schema.ListAttribute{
 				Description: "Cluster ids to attach component",
@@ -116,18 +164,24 @@ func (r *componentResource) Schema(_ context.Context, _ resource.SchemaRequest,
 				ElementType: types.NumberType,
 			},
 			"channels": schema.ListAttribute{
-				Description: "Channels to restrict component availability",
-				Required:    true,
+				Description: "Channels to restrict component availability, defaults to channels of the provider defaults block",
+				Optional:    true,
+				Computed:    true,
 				ElementType: types.StringType,
 			},
 			"insterra_component_id": schema.Int64Attribute{
 				Description: "Reference to insterra component",
 				Optional:    true,
-			},
-			"last_updated": schema.StringAttribute{
-				Description: "Timestamp of terraform update",
 				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+					int64planmodifier.RequiresReplaceIfConfigured(),
+				},
 			},
+			"organization": organization.ResourceAttribute(),
+			"created_at":   timestamps.CreatedAtAttribute(),
+			"updated_at":   timestamps.UpdatedAtAttribute(),
+			"last_updated": timestamps.LastUpdatedAttribute(),
 		},
 		Blocks: map[string]schema.Block{
 			"credential": schema.SingleNestedBlock{
@@ -163,6 +217,7 @@ func (r *componentResource) Schema(_ context.Context, _ resource.SchemaRequest,
 					},
 				},
 			},
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{Create: true, Update: true, Delete: true}),
 		},
 	}
 }
@@ -172,13 +227,13 @@ func (r *componentResource) Configure(_ context.Context, req resource.ConfigureR
 		return
 	}
 
-	client, ok := req.ProviderData.(*instc.Client)
+	client, ok := req.ProviderData.(*apiclient.Client)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
 			fmt.Sprintf(
-				"Expected *instc.Client, got: %T. Please report this issue to the provider developers.",
+				"Expected *apiclient.Client, got: %T. Please report this issue to the provider developers.",
 				req.ProviderData,
 			),
 		)
@@ -188,6 +243,18 @@ func (r *componentResource) Configure(_ context.Context, req resource.ConfigureR
 	r.client = client
 }
 
+func (r *componentResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	providerDefaults := r.client.Defaults()
+
+	defaults.String(ctx, req, resp, path.Root("provider_name"), providerDefaults.ProviderName)
+	defaults.RequiresReplace(ctx, req, resp, path.Root("provider_name"))
+	defaults.StringList(ctx, req, resp, path.Root("channels"), providerDefaults.Channels)
+}
+
 func (r *componentResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var plan componentResourceModel
 	diags := req.Plan.Get(ctx, &plan)
@@ -198,6 +265,13 @@ func (r *componentResource) Create(ctx context.Context, req resource.CreateReque
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Create, plan.Timeouts.Create, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var ClusterIDS []int
 	var Channels []string
 	var Credential componentCredentialResourceModel
@@ -239,20 +313,42 @@ func (r *componentResource) Create(ctx context.Context, req resource.CreateReque
 		Credential:          &credentialParams,
 	}
 
-	component, err := r.client.CreateComponent(componentParams)
+	component, err := r.client.WithContext(ctx).CreateComponent(componentParams)
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error creating instellar component",
-			"Cloud not create component, unexpected error: "+err.Error(),
-		)
+	if kind.Failed(&resp.Diagnostics, operation.Create, err) {
 		return
 	}
 
 	plan.ID = types.StringValue(strconv.Itoa(component.Data.Attributes.ID))
 	plan.Slug = types.StringValue(component.Data.Attributes.Slug)
 	plan.CurrentState = types.StringValue(component.Data.Attributes.CurrentState)
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	var (
+		reported            apiclient.Reported
+		insterraComponentID int
+	)
+
+	read := func(ctx context.Context) (string, error) {
+		component, err := r.client.GetComponent(apiclient.WithoutCache(ctx), plan.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		reported = component.Reported
+		insterraComponentID = component.InsterraComponentID
+
+		return component.Data.Attributes.CurrentState, nil
+	}
+
+	kind.AwaitReady(ctx, &resp.Diagnostics, plan.ID.ValueString(), read, &plan.CurrentState)
+
+	if insterraComponentID != 0 {
+		plan.InsterraComponentID = types.Int64Value(int64(insterraComponentID))
+	} else if plan.InsterraComponentID.IsUnknown() {
+		plan.InsterraComponentID = types.Int64Null()
+	}
+
+	timestamps.Set(ctx, reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -270,13 +366,11 @@ func (r *componentResource) Read(ctx context.Context, req resource.ReadRequest,
 		return
 	}
 
-	component, err := r.client.GetComponent(state.ID.ValueString())
+	ctx = apiclient.WithOrganization(ctx, state.Organization.ValueString())
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading instellar component",
-			"Cloud not read component id "+state.ID.ValueString()+": "+err.Error(),
-		)
+	component, err := r.client.GetComponent(ctx, state.ID.ValueString())
+
+	if kind.Refresh(ctx, resp, state.ID.ValueString(), err) {
 		return
 	}
 
@@ -287,6 +381,10 @@ func (r *componentResource) Read(ctx context.Context, req resource.ReadRequest,
 	state.ProviderName = types.StringValue(component.Data.Attributes.Provider)
 	state.DriverVersion = types.StringValue(component.Data.Attributes.Version)
 
+	if component.InsterraComponentID != 0 {
+		state.InsterraComponentID = types.Int64Value(int64(component.InsterraComponentID))
+	}
+
 	ClusterIDS, d := types.ListValueFrom(ctx, types.NumberType, component.Data.Attributes.ClusterIDS)
 
 	resp.Diagnostics.Append(d...)
@@ -335,6 +433,8 @@ func (r *componentResource) Read(ctx context.Context, req resource.ReadRequest,
 
 	state.Credential = Credential
 
+	timestamps.Refresh(ctx, component.Reported, &state.CreatedAt, &state.UpdatedAt, &state.LastUpdated)
+
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -350,6 +450,13 @@ func (r *componentResource) Update(ctx context.Context, req resource.UpdateReque
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Update, plan.Timeouts.Update, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var ClusterIDS []int
 	var Channels []string
 	var Credential componentCredentialResourceModel
@@ -384,22 +491,15 @@ func (r *componentResource) Update(ctx context.Context, req resource.UpdateReque
 		Credential: &credentialParams,
 	}
 
-	_, err := r.client.UpdateComponent(plan.ID.ValueString(), componentParams)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error updating instellar component",
-			"Could not update component, unexpected error: "+err.Error(),
-		)
+	_, err := r.client.WithContext(ctx).UpdateComponent(plan.ID.ValueString(), componentParams)
+
+	if kind.Failed(&resp.Diagnostics, operation.Update, err) {
 		return
 	}
 
-	component, err := r.client.GetComponent(plan.ID.ValueString())
+	component, err := r.client.GetComponent(ctx, plan.ID.ValueString())
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading instellar component",
-			"Could not read instellar component ID "+plan.ID.ValueString()+": "+err.Error(),
-		)
+	if kind.ReadFailed(&resp.Diagnostics, plan.ID.ValueString(), err) {
 		return
 	}
 
@@ -418,7 +518,14 @@ func (r *componentResource) Update(ctx context.Context, req resource.UpdateReque
 	plan.CurrentState = types.StringValue(component.Data.Attributes.CurrentState)
 	plan.ClusterIDS = resultClusterIDS
 	plan.Channels = resultChannels
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	if component.InsterraComponentID != 0 {
+		plan.InsterraComponentID = types.Int64Value(int64(component.InsterraComponentID))
+	} else if plan.InsterraComponentID.IsUnknown() {
+		plan.InsterraComponentID = types.Int64Null()
+	}
+
+	timestamps.Set(ctx, component.Reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -435,16 +542,33 @@ func (r *componentResource) Delete(ctx context.Context, req resource.DeleteReque
 		return
 	}
 
-	_, err := r.client.DeleteComponent(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error deleting component",
-			"cloud not delete component, unexpected error: "+err.Error(),
-		)
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Delete, state.Timeouts.Delete, state.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	_, err := r.client.WithContext(ctx).DeleteComponent(state.ID.ValueString())
+
+	if apiclient.IsNotFound(err) {
+		return
+	}
+
+	if kind.Failed(&resp.Diagnostics, operation.Delete, err) {
+		return
+	}
+
+	kind.AwaitDeletion(ctx, &resp.Diagnostics, state.ID.ValueString(), func(ctx context.Context) (string, error) {
+		component, err := r.client.GetComponent(apiclient.WithoutCache(ctx), state.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		return component.Data.Attributes.CurrentState, nil
+	})
 }
 
 func (r *componentResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	organization.ImportState(ctx, req, resp)
 }
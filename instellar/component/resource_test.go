@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/upmaru/terraform-provider-instellar/internal/acceptance"
+	"github.com/upmaru/terraform-provider-instellar/internal/acceptancetest"
 )
 
 func TestAccComponentResource(t *testing.T) {
@@ -17,8 +19,7 @@ func TestAccComponentResource(t *testing.T) {
 
 	componentName := fmt.Sprintf("%s-db", clusterNameSlug)
 
-	resource.ParallelTest(t, resource.TestCase{
-		ProtoV6ProviderFactories: acceptance.TestAccProtoV6ProviderFactories,
+	acceptance.ParallelTest(t, resource.TestCase{
 		Steps: []resource.TestStep{
 			{
 				Config: buildConfig(clusterNameSlug, componentName, "15.2", `["develop"]`),
@@ -30,14 +31,16 @@ func TestAccComponentResource(t *testing.T) {
 					resource.TestCheckResourceAttr("instellar_component.test", "channels.#", "1"),
 					// Verify dynamic vlaues have value set
 					resource.TestCheckResourceAttrSet("instellar_component.test", "id"),
-					resource.TestCheckResourceAttrSet("instellar_component.test", "last_updated"),
+					resource.TestCheckResourceAttrSet("instellar_component.test", "created_at"),
+					resource.TestCheckResourceAttrSet("instellar_component.test", "updated_at"),
+					resource.TestCheckResourceAttrPair("instellar_component.test", "last_updated", "instellar_component.test", "updated_at"),
 				),
 			},
 			{
 				ResourceName:            "instellar_component.test",
 				ImportState:             true,
 				ImportStateVerify:       true,
-				ImportStateVerifyIgnore: []string{"last_updated", "current_state", "insterra_component_id"},
+				ImportStateVerifyIgnore: []string{"current_state", "insterra_component_id"},
 			},
 			{
 				Config: buildConfigWithCert(clusterNameSlug, componentName, "15.5", `["develop", "master"]`),
@@ -51,7 +54,9 @@ func TestAccComponentResource(t *testing.T) {
 					resource.TestCheckResourceAttr("instellar_component.test", "channels.#", "2"),
 					// Verify dynamic values have value set
 					resource.TestCheckResourceAttrSet("instellar_component.test", "id"),
-					resource.TestCheckResourceAttrSet("instellar_component.test", "last_updated"),
+					resource.TestCheckResourceAttrSet("instellar_component.test", "created_at"),
+					resource.TestCheckResourceAttrSet("instellar_component.test", "updated_at"),
+					resource.TestCheckResourceAttrPair("instellar_component.test", "last_updated", "instellar_component.test", "updated_at"),
 				),
 			},
 		},
@@ -124,3 +129,56 @@ func buildConfigWithCert(clusterName string, componentName string, version strin
 		}
 	`, clusterName, componentName, version, channels)
 }
+
+func TestComponentResourcePlanReplacesComponent(t *testing.T) {
+	stringList := tftypes.List{ElementType: tftypes.String}
+	numberList := tftypes.List{ElementType: tftypes.Number}
+
+	prior := map[string]tftypes.Value{
+		"id":                    tftypes.NewValue(tftypes.String, "1"),
+		"name":                  tftypes.NewValue(tftypes.String, "database"),
+		"slug":                  tftypes.NewValue(tftypes.String, "database"),
+		"current_state":         tftypes.NewValue(tftypes.String, "active"),
+		"driver_version":        tftypes.NewValue(tftypes.String, "15"),
+		"provider_name":         tftypes.NewValue(tftypes.String, "aws"),
+		"driver":                tftypes.NewValue(tftypes.String, "database/postgresql"),
+		"cluster_ids":           tftypes.NewValue(numberList, []tftypes.Value{tftypes.NewValue(tftypes.Number, 1)}),
+		"channels":              tftypes.NewValue(stringList, []tftypes.Value{tftypes.NewValue(tftypes.String, "develop")}),
+		"last_updated":          tftypes.NewValue(tftypes.String, "2024-01-01T00:00:00Z"),
+		"insterra_component_id": tftypes.NewValue(tftypes.Number, 7),
+	}
+
+	testCases := map[string]struct {
+		value    tftypes.Value
+		replaced bool
+	}{
+		"name":                  {value: tftypes.NewValue(tftypes.String, "another-database"), replaced: true},
+		"driver":                {value: tftypes.NewValue(tftypes.String, "bucket/s3"), replaced: true},
+		"provider_name":         {value: tftypes.NewValue(tftypes.String, "hcloud"), replaced: true},
+		"driver_version":        {value: tftypes.NewValue(tftypes.String, "16"), replaced: false},
+		"channels":              {value: tftypes.NewValue(stringList, []tftypes.Value{tftypes.NewValue(tftypes.String, "master")}), replaced: false},
+		"cluster_ids":           {value: tftypes.NewValue(numberList, []tftypes.Value{tftypes.NewValue(tftypes.Number, 2)}), replaced: false},
+		"insterra_component_id": {value: tftypes.NewValue(tftypes.Number, 8), replaced: true},
+	}
+
+	for attribute, testCase := range testCases {
+		t.Run(attribute, func(t *testing.T) {
+			config := map[string]tftypes.Value{
+				"name":                  prior["name"],
+				"driver_version":        prior["driver_version"],
+				"provider_name":         prior["provider_name"],
+				"driver":                prior["driver"],
+				"cluster_ids":           prior["cluster_ids"],
+				"channels":              prior["channels"],
+				"insterra_component_id": prior["insterra_component_id"],
+			}
+			config[attribute] = testCase.value
+
+			resp := acceptancetest.PlanUpdate(t, nil, "instellar_component", prior, config)
+
+			if replaced := acceptancetest.RequiresReplace(resp, attribute); replaced != testCase.replaced {
+				t.Fatalf("expected replacement %t for %s, got %v", testCase.replaced, attribute, resp.RequiresReplace)
+			}
+		})
+	}
+}
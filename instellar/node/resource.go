@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
-	"time"
 
 	instc "github.com/upmaru/instellar-go"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -17,6 +17,12 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+	"github.com/upmaru/terraform-provider-instellar/internal/operation"
+	"github.com/upmaru/terraform-provider-instellar/internal/organization"
+	"github.com/upmaru/terraform-provider-instellar/internal/timestamps"
+	"github.com/upmaru/terraform-provider-instellar/internal/wait"
 )
 
 var (
@@ -25,28 +31,47 @@ var (
 	_ resource.ResourceWithImportState = &nodeResource{}
 )
 
+// states are the states Create tells apart while waiting for a node, the API
+// moves it from created to healthy.
+var states = wait.States{Ready: []string{"healthy"}, Failed: wait.FailedStates}
+
+// apiAttributes maps the fields of API validation errors to the attributes
+// they come from.
+var apiAttributes = map[string]path.Path{
+	"slug":       path.Root("slug"),
+	"public_ip":  path.Root("public_ip"),
+	"cluster_id": path.Root("cluster_id"),
+}
+
+// kind describes nodes to the steps every resource shares.
+var kind = operation.Kind{Name: "node", Attributes: apiAttributes, States: states}
+
 func NewNodeResource() resource.Resource {
 	return &nodeResource{}
 }
 
 type nodeResource struct {
-	client *instc.Client
+	client *apiclient.Client
 }
 
 type nodeResourceModel struct {
-	ID           types.String `tfsdk:"id"`
-	Slug         types.String `tfsdk:"slug"`
-	ClusterID    types.String `tfsdk:"cluster_id"`
-	PublicIP     types.String `tfsdk:"public_ip"`
-	CurrentState types.String `tfsdk:"current_state"`
-	LastUpdated  types.String `tfsdk:"last_updated"`
+	ID           types.String   `tfsdk:"id"`
+	Slug         types.String   `tfsdk:"slug"`
+	ClusterID    types.String   `tfsdk:"cluster_id"`
+	PublicIP     types.String   `tfsdk:"public_ip"`
+	CurrentState types.String   `tfsdk:"current_state"`
+	Organization types.String   `tfsdk:"organization"`
+	Timeouts     timeouts.Value `tfsdk:"timeouts"`
+	CreatedAt    types.String   `tfsdk:"created_at"`
+	UpdatedAt    types.String   `tfsdk:"updated_at"`
+	LastUpdated  types.String   `tfsdk:"last_updated"`
 }
 
 func (r *nodeResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_node"
 }
 
-func (r *nodeResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *nodeResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Nodes are representation of the low level machine running your cluster. This can be a VM or a Bare Metal machine.",
 		Attributes: map[string]schema.Attribute{
@@ -60,6 +85,9 @@ func (r *nodeResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 			"slug": schema.StringAttribute{
 				Description: "Node slug",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 				Validators: []validator.String{
 					stringvalidator.RegexMatches(
 						regexp.MustCompile(`^[a-z0-9\-]+$`),
@@ -74,15 +102,21 @@ func (r *nodeResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 			"cluster_id": schema.StringAttribute{
 				Description: "Cluster ID",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"public_ip": schema.StringAttribute{
 				Description: "Public IP of the node",
 				Required:    true,
 			},
-			"last_updated": schema.StringAttribute{
-				Description: "Timestamp of terraform update",
-				Computed:    true,
-			},
+			"organization": organization.ResourceAttribute(),
+			"created_at":   timestamps.CreatedAtAttribute(),
+			"updated_at":   timestamps.UpdatedAtAttribute(),
+			"last_updated": timestamps.LastUpdatedAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{Create: true, Update: true, Delete: true}),
 		},
 	}
 }
@@ -92,13 +126,13 @@ func (r *nodeResource) Configure(_ context.Context, req resource.ConfigureReques
 		return
 	}
 
-	client, ok := req.ProviderData.(*instc.Client)
+	client, ok := req.ProviderData.(*apiclient.Client)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
 			fmt.Sprintf(
-				"Expected *instc.Client, got: %T. Please report this issue to the provider developers.",
+				"Expected *apiclient.Client, got: %T. Please report this issue to the provider developers.",
 				req.ProviderData,
 			),
 		)
@@ -118,23 +152,42 @@ func (r *nodeResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Create, plan.Timeouts.Create, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	nodeParams := instc.NodeParams{
 		PublicIP: plan.PublicIP.ValueString(),
 	}
 
-	node, err := r.client.CreateNode(plan.ClusterID.ValueString(), plan.Slug.ValueString(), nodeParams)
+	node, err := r.client.WithContext(ctx).CreateNode(plan.ClusterID.ValueString(), plan.Slug.ValueString(), nodeParams)
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error creating node",
-			"Could not create node, unexpected error: "+err.Error(),
-		)
+	if kind.Failed(&resp.Diagnostics, operation.Create, err) {
 		return
 	}
 
 	plan.ID = types.StringValue(strconv.Itoa(node.Data.Attributes.ID))
 	plan.CurrentState = types.StringValue(node.Data.Attributes.CurrentState)
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	var reported apiclient.Reported
+
+	read := func(ctx context.Context) (string, error) {
+		node, err := r.client.GetNode(apiclient.WithoutCache(ctx), plan.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		reported = node.Reported
+
+		return node.Data.Attributes.CurrentState, nil
+	}
+
+	kind.AwaitReady(ctx, &resp.Diagnostics, plan.ID.ValueString(), read, &plan.CurrentState)
+
+	timestamps.Set(ctx, reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -152,13 +205,11 @@ func (r *nodeResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	node, err := r.client.GetNode(state.ID.ValueString())
+	ctx = apiclient.WithOrganization(ctx, state.Organization.ValueString())
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading node",
-			"Could not read node id "+state.ID.ValueString()+": "+err.Error(),
-		)
+	node, err := r.client.GetNode(ctx, state.ID.ValueString())
+
+	if kind.Refresh(ctx, resp, state.ID.ValueString(), err) {
 		return
 	}
 
@@ -167,6 +218,8 @@ func (r *nodeResource) Read(ctx context.Context, req resource.ReadRequest, resp
 	state.ClusterID = types.StringValue(strconv.Itoa(node.Data.Attributes.ClusterID))
 	state.PublicIP = types.StringValue(node.Data.Attributes.PublicIP)
 
+	timestamps.Refresh(ctx, node.Reported, &state.CreatedAt, &state.UpdatedAt, &state.LastUpdated)
+
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -182,33 +235,34 @@ func (r *nodeResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Update, plan.Timeouts.Update, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	nodeParams := instc.NodeParams{
 		PublicIP: plan.PublicIP.ValueString(),
 	}
 
-	_, err := r.client.UpdateNode(plan.ClusterID.ValueString(), plan.Slug.ValueString(), nodeParams)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error updating node",
-			"Could not update node, unexpected error: "+err.Error(),
-		)
+	_, err := r.client.WithContext(ctx).UpdateNode(plan.ClusterID.ValueString(), plan.Slug.ValueString(), nodeParams)
+
+	if kind.Failed(&resp.Diagnostics, operation.Update, err) {
 		return
 	}
 
-	node, err := r.client.GetNode(plan.ID.ValueString())
+	node, err := r.client.GetNode(ctx, plan.ID.ValueString())
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading node",
-			"Could not read node ID "+plan.ID.ValueString()+": "+err.Error(),
-		)
+	if kind.ReadFailed(&resp.Diagnostics, plan.ID.ValueString(), err) {
 		return
 	}
 
 	plan.Slug = types.StringValue(node.Data.Attributes.Slug)
 	plan.CurrentState = types.StringValue(node.Data.Attributes.CurrentState)
 	plan.PublicIP = types.StringValue(node.Data.Attributes.PublicIP)
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	timestamps.Set(ctx, node.Reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -225,16 +279,33 @@ func (r *nodeResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	_, err := r.client.DeleteNode(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error deleting node",
-			"Could not delete node, unexpected error: "+err.Error(),
-		)
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Delete, state.Timeouts.Delete, state.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	_, err := r.client.WithContext(ctx).DeleteNode(state.ID.ValueString())
+
+	if apiclient.IsNotFound(err) {
+		return
+	}
+
+	if kind.Failed(&resp.Diagnostics, operation.Delete, err) {
+		return
+	}
+
+	kind.AwaitDeletion(ctx, &resp.Diagnostics, state.ID.ValueString(), func(ctx context.Context) (string, error) {
+		node, err := r.client.GetNode(apiclient.WithoutCache(ctx), state.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		return node.Data.Attributes.CurrentState, nil
+	})
 }
 
 func (r *nodeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	organization.ImportState(ctx, req, resp)
 }
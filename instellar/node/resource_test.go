@@ -1,13 +1,25 @@
 package node_test
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/upmaru/terraform-provider-instellar/instellar/node"
 	"github.com/upmaru/terraform-provider-instellar/internal/acceptance"
+	"github.com/upmaru/terraform-provider-instellar/internal/acceptancetest"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+
+	// instellar client = instc.
+	instc "github.com/upmaru/instellar-go"
 )
 
 func TestAccNodeResource(t *testing.T) {
@@ -15,25 +27,26 @@ func TestAccNodeResource(t *testing.T) {
 	clusterNameSegments := strings.Split(clusterUUID.String(), "-")
 	clusterNameSlug := strings.Join([]string{clusterNameSegments[0], clusterNameSegments[1]}, "-")
 
-	resource.ParallelTest(t, resource.TestCase{
-		ProtoV6ProviderFactories: acceptance.TestAccProtoV6ProviderFactories,
+	acceptance.ParallelTest(t, resource.TestCase{
 		Steps: []resource.TestStep{
 			{
 				Config: buildConfig(clusterNameSlug, "127.0.0.1"),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("instellar_node.test", "slug", "pizza-node-ham"),
 					// Verify computed attribute fields.
-					resource.TestCheckResourceAttr("instellar_node.test", "current_state", "created"),
+					resource.TestCheckResourceAttr("instellar_node.test", "current_state", "healthy"),
 					// Dynamic values
 					resource.TestCheckResourceAttrSet("instellar_node.test", "id"),
-					resource.TestCheckResourceAttrSet("instellar_node.test", "last_updated"),
+					resource.TestCheckResourceAttrSet("instellar_node.test", "created_at"),
+					resource.TestCheckResourceAttrSet("instellar_node.test", "updated_at"),
+					resource.TestCheckResourceAttrPair("instellar_node.test", "last_updated", "instellar_node.test", "updated_at"),
 				),
 			},
 			{
 				ResourceName:            "instellar_node.test",
 				ImportState:             true,
 				ImportStateVerify:       true,
-				ImportStateVerifyIgnore: []string{"last_updated", "current_state"},
+				ImportStateVerifyIgnore: []string{"current_state"},
 			},
 			{
 				Config: buildConfig(clusterNameSlug, "38.56.93.42"),
@@ -43,7 +56,9 @@ func TestAccNodeResource(t *testing.T) {
 					resource.TestCheckResourceAttr("instellar_node.test", "current_state", "syncing"),
 					// Dynamic values
 					resource.TestCheckResourceAttrSet("instellar_node.test", "id"),
-					resource.TestCheckResourceAttrSet("instellar_node.test", "last_updated"),
+					resource.TestCheckResourceAttrSet("instellar_node.test", "created_at"),
+					resource.TestCheckResourceAttrSet("instellar_node.test", "updated_at"),
+					resource.TestCheckResourceAttrPair("instellar_node.test", "last_updated", "instellar_node.test", "updated_at"),
 				),
 			},
 		},
@@ -73,3 +88,83 @@ func buildConfig(clusterNameSlug string, publicIp string) string {
 		}
 	`, publicIp)
 }
+
+func TestNodeResourceCreateSetsServerTimestamps(t *testing.T) {
+	client := acceptancetest.MemoryClient(t, apiclient.Options{})
+
+	cluster, err := client.WithContext(context.Background()).CreateCluster(instc.ClusterParams{
+		Name:     "pizza",
+		Provider: "aws",
+		Region:   "ap-southeast-1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resp := acceptancetest.Create(t, node.NewNodeResource(), client, map[string]tftypes.Value{
+		"slug":       tftypes.NewValue(tftypes.String, "pizza-node-01"),
+		"public_ip":  tftypes.NewValue(tftypes.String, "10.0.0.1"),
+		"cluster_id": tftypes.NewValue(tftypes.String, strconv.Itoa(cluster.Data.Attributes.ID)),
+	})
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	values := map[string]types.String{}
+
+	for _, attribute := range []string{"created_at", "updated_at", "last_updated"} {
+		var value types.String
+
+		if diags := resp.State.GetAttribute(context.Background(), path.Root(attribute), &value); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+
+		values[attribute] = value
+	}
+
+	if _, err := time.Parse(time.RFC3339, values["created_at"].ValueString()); err != nil {
+		t.Errorf("expected created_at in RFC 3339 format, got %s", values["created_at"])
+	}
+
+	if !values["last_updated"].Equal(values["updated_at"]) || values["updated_at"].IsNull() {
+		t.Errorf("expected last_updated to match updated_at, got %s and %s", values["last_updated"], values["updated_at"])
+	}
+}
+
+func TestNodeResourcePlanReplacesNode(t *testing.T) {
+	prior := map[string]tftypes.Value{
+		"id":            tftypes.NewValue(tftypes.String, "1"),
+		"slug":          tftypes.NewValue(tftypes.String, "node-01"),
+		"current_state": tftypes.NewValue(tftypes.String, "healthy"),
+		"cluster_id":    tftypes.NewValue(tftypes.String, "1"),
+		"public_ip":     tftypes.NewValue(tftypes.String, "127.0.0.1"),
+		"last_updated":  tftypes.NewValue(tftypes.String, "2024-01-01T00:00:00Z"),
+	}
+
+	testCases := map[string]struct {
+		value    tftypes.Value
+		replaced bool
+	}{
+		"slug":       {value: tftypes.NewValue(tftypes.String, "node-02"), replaced: true},
+		"cluster_id": {value: tftypes.NewValue(tftypes.String, "2"), replaced: true},
+		"public_ip":  {value: tftypes.NewValue(tftypes.String, "127.0.0.2"), replaced: false},
+	}
+
+	for attribute, testCase := range testCases {
+		t.Run(attribute, func(t *testing.T) {
+			config := map[string]tftypes.Value{
+				"slug":       prior["slug"],
+				"cluster_id": prior["cluster_id"],
+				"public_ip":  prior["public_ip"],
+			}
+			config[attribute] = testCase.value
+
+			resp := acceptancetest.PlanUpdate(t, nil, "instellar_node", prior, config)
+
+			if replaced := acceptancetest.RequiresReplace(resp, attribute); replaced != testCase.replaced {
+				t.Fatalf("expected replacement %t for %s, got %v", testCase.replaced, attribute, resp.RequiresReplace)
+			}
+		})
+	}
+}
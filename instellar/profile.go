@@ -0,0 +1,202 @@
+package instellar
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+const (
+	defaultHost    = "https://web.instellar.app"
+	defaultProfile = "default"
+)
+
+// profile is a named set of settings in the instellar credentials file,
+// written in TOML or INI.
+//
+//	[staging]
+//	host       = "https://staging.instellar.app"
+//	auth_token = "..."
+type profile struct {
+	Host      string `toml:"host"`
+	AuthToken string `toml:"auth_token"`
+}
+
+// resolveCredentials returns the host and auth token used by the provider.
+// Both come from the same source so a credential is never sent to the host
+// of another source: values set in the configuration take precedence over
+// the INSTELLAR_HOST and INSTELLAR_AUTH_TOKEN env variables, either of which
+// takes precedence over the default profile of the credentials file. A
+// profile named explicitly is the only source, naming one while a host or a
+// credential is also set is an error.
+func resolveCredentials(config instellarProviderModel, diags *diag.Diagnostics) (string, string) {
+	host := stringValue(config.Host, "INSTELLAR_HOST")
+	authToken := stringValue(config.AuthToken, "INSTELLAR_AUTH_TOKEN")
+
+	explicit := host != "" || authToken != "" ||
+		!config.AuthTokenCommand.IsNull() ||
+		stringValue(config.ClientID, "INSTELLAR_CLIENT_ID") != ""
+
+	name := stringValue(config.Profile, "INSTELLAR_PROFILE")
+
+	switch {
+	case name != "" && explicit:
+		diags.AddAttributeError(
+			path.Root("profile"),
+			"Conflicting Instellar Credentials",
+			"The provider is configured to read profile "+name+" while a host or a credential is also set in the configuration or the env variables. "+
+				"Remove the profile or the other settings so the host and credentials come from a single source.",
+		)
+	case !explicit:
+		selected := loadProfile(config, name, diags)
+
+		host = selected.Host
+		authToken = selected.AuthToken
+	}
+
+	if host == "" {
+		host = defaultHost
+	}
+
+	return host, authToken
+}
+
+// loadProfile reads profile name from the credentials file. When no name is
+// given the default profile is used if the credentials file defines one.
+func loadProfile(config instellarProviderModel, name string, diags *diag.Diagnostics) profile {
+	explicit := name != ""
+
+	if !explicit {
+		name = defaultProfile
+	}
+
+	file := stringValue(config.CredentialsFile, "INSTELLAR_CREDENTIALS_FILE")
+
+	if file == "" {
+		file = defaultCredentialsFile()
+	}
+
+	profiles, err := readProfiles(file)
+
+	switch {
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		return profile{}
+	case err != nil:
+		diags.AddAttributeError(
+			path.Root("credentials_file"),
+			"Unable to Read Instellar Credentials File",
+			"The provider cannot read profile "+name+" from the credentials file "+file+": "+err.Error(),
+		)
+		return profile{}
+	}
+
+	selected, ok := profiles[name]
+
+	if !ok && explicit {
+		diags.AddAttributeError(
+			path.Root("profile"),
+			"Unknown Instellar Profile",
+			"The provider cannot find profile "+name+" in the credentials file "+file+". "+
+				"Add a ["+name+"] section to the file or select another profile.",
+		)
+	}
+
+	return selected
+}
+
+// readProfiles reads the profiles of the credentials file, written in TOML
+// or INI.
+func readProfiles(file string) (map[string]profile, error) {
+	document, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles map[string]profile
+
+	_, tomlErr := toml.Decode(string(document), &profiles)
+	if tomlErr == nil {
+		return profiles, nil
+	}
+
+	profiles, iniErr := decodeINI(string(document))
+	if iniErr != nil {
+		return nil, fmt.Errorf("the file is neither TOML (%s) nor INI (%s)", tomlErr, iniErr)
+	}
+
+	return profiles, nil
+}
+
+// decodeINI reads profiles from an INI document. Every section is a profile,
+// values may be quoted and lines starting with ; or # are comments.
+//
+//	[staging]
+//	host       = https://staging.instellar.app
+//	auth_token = ...
+func decodeINI(document string) (map[string]profile, error) {
+	profiles := map[string]profile{}
+	section := ""
+
+	for number, line := range strings.Split(document, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := profiles[section]; !ok {
+				profiles[section] = profile{}
+			}
+
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found || section == "" {
+			return nil, fmt.Errorf("line %d is neither a section nor a key = value pair of a section", number+1)
+		}
+
+		value = strings.TrimSpace(value)
+
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		selected := profiles[section]
+
+		switch strings.TrimSpace(key) {
+		case "host":
+			selected.Host = value
+		case "auth_token":
+			selected.AuthToken = value
+		}
+
+		profiles[section] = selected
+	}
+
+	return profiles, nil
+}
+
+// defaultCredentialsFile returns $XDG_CONFIG_HOME/instellar/credentials,
+// falling back to ~/.config/instellar/credentials.
+func defaultCredentialsFile() string {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "instellar", "credentials")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".config", "instellar", "credentials")
+}
@@ -2,44 +2,97 @@ package instellar
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
-
-	// instellar client = instc.
-	instc "github.com/upmaru/instellar-go"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+
 	"github.com/upmaru/terraform-provider-instellar/instellar/balancer"
 	"github.com/upmaru/terraform-provider-instellar/instellar/cluster"
 	"github.com/upmaru/terraform-provider-instellar/instellar/component"
 	"github.com/upmaru/terraform-provider-instellar/instellar/node"
 	"github.com/upmaru/terraform-provider-instellar/instellar/storage"
 	"github.com/upmaru/terraform-provider-instellar/instellar/uplink"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
 )
 
 var (
 	_ provider.Provider = &instellarProvider{}
 )
 
-func New() provider.Provider {
-	return &instellarProvider{}
+func New(version string) func() provider.Provider {
+	return func() provider.Provider {
+		return &instellarProvider{
+			version: version,
+		}
+	}
 }
 
-type instellarProvider struct{}
+type instellarProvider struct {
+	// version is the released version of the provider, "dev" for local
+	// builds and "test" for acceptance tests.
+	version string
+}
 
 type instellarProviderModel struct {
-	Host      types.String `tfsdk:"host"`
-	AuthToken types.String `tfsdk:"auth_token"`
+	Host                      types.String `tfsdk:"host"`
+	AuthToken                 types.String `tfsdk:"auth_token"`
+	AuthTokenCommand          types.List   `tfsdk:"auth_token_command"`
+	ClientID                  types.String `tfsdk:"client_id"`
+	ClientSecret              types.String `tfsdk:"client_secret"`
+	TokenURL                  types.String `tfsdk:"token_url"`
+	Profile                   types.String `tfsdk:"profile"`
+	CredentialsFile           types.String `tfsdk:"credentials_file"`
+	SkipCredentialsValidation types.Bool   `tfsdk:"skip_credentials_validation"`
+	MaxRetries                types.Int64  `tfsdk:"max_retries"`
+	RetryMaxWait              types.String `tfsdk:"retry_max_wait"`
+	RequestTimeout            types.String `tfsdk:"request_timeout"`
+	MaxConcurrentRequests     types.Int64  `tfsdk:"max_concurrent_requests"`
+	RequestsPerSecond         types.Int64  `tfsdk:"requests_per_second"`
+	CACertPEM                 types.String `tfsdk:"ca_cert_pem"`
+	CACertFile                types.String `tfsdk:"ca_cert_file"`
+	ClientCertPEM             types.String `tfsdk:"client_cert_pem"`
+	ClientKeyPEM              types.String `tfsdk:"client_key_pem"`
+	InsecureSkipVerify        types.Bool   `tfsdk:"insecure_skip_verify"`
+	ProxyURL                  types.String `tfsdk:"proxy_url"`
+	Headers                   types.Map    `tfsdk:"headers"`
+	UserAgentSuffix           types.String `tfsdk:"user_agent_suffix"`
+	ReadOnly                  types.Bool   `tfsdk:"read_only"`
+	Organization              types.String `tfsdk:"organization"`
+	Defaults                  types.Object `tfsdk:"defaults"`
+}
+
+// instellarDefaultsModel maps the defaults block.
+type instellarDefaultsModel struct {
+	ProviderName types.String `tfsdk:"provider_name"`
+	Region       types.String `tfsdk:"region"`
+	Channels     types.List   `tfsdk:"channels"`
 }
 
 func (p *instellarProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "instellar"
+	resp.Version = p.version
 }
 
 func (p *instellarProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
@@ -47,7 +100,7 @@ func (p *instellarProvider) Schema(_ context.Context, _ provider.SchemaRequest,
 		Description: "Provision instellar resources.",
 		Attributes: map[string]schema.Attribute{
 			"host": schema.StringAttribute{
-				Description: "Host for instellar API. May also be provided via INSTELLAR_HOST env variable.",
+				Description: "Host for instellar API. May also be provided via INSTELLAR_HOST env variable. Set it to \"memory://\" to use an in-memory backend without an Instellar account, optionally followed by a file path keeping the objects across runs such as \"memory://.instellar.json\".",
 				Optional:    true,
 			},
 			"auth_token": schema.StringAttribute{
@@ -55,6 +108,148 @@ func (p *instellarProvider) Schema(_ context.Context, _ provider.SchemaRequest,
 				Optional:    true,
 				Sensitive:   true,
 			},
+			"auth_token_command": schema.ListAttribute{
+				Description: "Command and arguments of a credential helper used instead of auth_token. " +
+					"It must print a JSON document with token and expires_at fields, and is run again when the token expires.",
+				Optional:    true,
+				ElementType: types.StringType,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.ConflictsWith(path.MatchRoot("auth_token")),
+				},
+			},
+			"client_id": schema.StringAttribute{
+				Description: "OAuth2 client id used instead of auth_token to obtain access tokens with the client credentials grant. May also be provided via INSTELLAR_CLIENT_ID env variable.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("auth_token"), path.MatchRoot("auth_token_command")),
+				},
+			},
+			"client_secret": schema.StringAttribute{
+				Description: "OAuth2 client secret. May also be provided via INSTELLAR_CLIENT_SECRET env variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"token_url": schema.StringAttribute{
+				Description: "OAuth2 token endpoint issuing access tokens. May also be provided via INSTELLAR_TOKEN_URL env variable.",
+				Optional:    true,
+			},
+			"profile": schema.StringAttribute{
+				Description: "Name of the profile in the credentials file providing host and auth_token. May also be provided via INSTELLAR_PROFILE env variable. " +
+					"The profile is the only source of the host and credentials, naming one while a host or a credential is also set in the configuration or env variables is an error.",
+				Optional: true,
+			},
+			"credentials_file": schema.StringAttribute{
+				Description: "Path to the TOML or INI credentials file containing named profiles. May also be provided via INSTELLAR_CREDENTIALS_FILE env variable. Defaults to ~/.config/instellar/credentials.",
+				Optional:    true,
+			},
+			"skip_credentials_validation": schema.BoolAttribute{
+				Description: "Skip validating the credentials against the instellar API when configuring the provider, for example to plan offline. May also be provided via INSTELLAR_SKIP_CREDENTIALS_VALIDATION env variable.",
+				Optional:    true,
+			},
+			"max_retries": schema.Int64Attribute{
+				Description: "Maximum number of times a failed API request is retried. Defaults to 4.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				Description: "Maximum number of API requests in flight at once, shared by every resource and data source of the provider. Unlimited when not set.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"requests_per_second": schema.Int64Attribute{
+				Description: "Maximum number of API requests sent per second, shared by every resource and data source of the provider. Unlimited when not set.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"retry_max_wait": schema.StringAttribute{
+				Description: "Maximum wait between two retries of an API request, for example \"30s\". Defaults to 30s.",
+				Optional:    true,
+			},
+			"request_timeout": schema.StringAttribute{
+				Description: "Maximum time a single API request may take, for example \"1m\". Defaults to 30s.",
+				Optional:    true,
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				Description: "PEM encoded CA certificate used to verify the instellar API host. May also be provided via INSTELLAR_CA_CERT_PEM env variable.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("ca_cert_file")),
+				},
+			},
+			"ca_cert_file": schema.StringAttribute{
+				Description: "Path to a PEM encoded CA certificate used to verify the instellar API host. May also be provided via INSTELLAR_CA_CERT_FILE env variable.",
+				Optional:    true,
+			},
+			"client_cert_pem": schema.StringAttribute{
+				Description: "PEM encoded client certificate for hosts requiring mutual TLS. May also be provided via INSTELLAR_CLIENT_CERT_PEM env variable.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("client_key_pem")),
+				},
+			},
+			"client_key_pem": schema.StringAttribute{
+				Description: "PEM encoded private key of the client certificate. May also be provided via INSTELLAR_CLIENT_KEY_PEM env variable.",
+				Optional:    true,
+				Sensitive:   true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("client_cert_pem")),
+				},
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				Description: "Skip TLS certificate verification of the instellar API host. Only use this for testing. May also be provided via INSTELLAR_INSECURE_SKIP_VERIFY env variable.",
+				Optional:    true,
+			},
+			"proxy_url": schema.StringAttribute{
+				Description: "URL of the proxy used for every API request. May also be provided via INSTELLAR_PROXY_URL env variable. Defaults to the proxy configured by the HTTPS_PROXY env variable.",
+				Optional:    true,
+			},
+			"headers": schema.MapAttribute{
+				Description: "Extra headers sent with every API request.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"user_agent_suffix": schema.StringAttribute{
+				Description: "Text appended to the User-Agent header of every API request, for example to tag a pipeline. May also be provided via INSTELLAR_USER_AGENT_SUFFIX env variable.",
+				Optional:    true,
+			},
+			"organization": schema.StringAttribute{
+				Description: "Organization the resources are managed in, for tokens with access to several organizations. Resources may override it with their own organization attribute. May also be provided via INSTELLAR_ORGANIZATION env variable.",
+				Optional:    true,
+			},
+			"read_only": schema.BoolAttribute{
+				Description: "Refuse to create, update or delete any resource. Reading resources and data sources keeps working, which makes it safe to plan against production. May also be provided via INSTELLAR_READ_ONLY env variable.",
+				Optional:    true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"defaults": schema.SingleNestedBlock{
+				Description: "Values used by resources for attributes left out of their configuration.",
+				Attributes: map[string]schema.Attribute{
+					"provider_name": schema.StringAttribute{
+						Description: "Default provider_name of clusters and components.",
+						Optional:    true,
+						Validators: []validator.String{
+							stringvalidator.OneOf([]string{"aws", "hcloud", "digitalocean", "google", "azurerm"}...),
+						},
+					},
+					"region": schema.StringAttribute{
+						Description: "Default region of clusters.",
+						Optional:    true,
+					},
+					"channels": schema.ListAttribute{
+						Description: "Default channels of components.",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+				},
+			},
 		},
 	}
 }
@@ -83,42 +278,24 @@ func (p *instellarProvider) Configure(ctx context.Context, req provider.Configur
 		return
 	}
 
-	host := os.Getenv("INSTELLAR_HOST")
-	auth_token := os.Getenv("INSTELLAR_AUTH_TOKEN")
-
-	if !config.Host.IsNull() {
-		host = config.Host.ValueString()
-	}
-
-	if !config.AuthToken.IsNull() {
-		auth_token = config.AuthToken.ValueString()
-	}
-
-	if host == "" {
-		host = "https://web.instellar.app"
-	}
-
-	if auth_token == "" {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("auth_token"),
-			"Missing Instellar API Auth Token",
-			"The provider cannot create Instellar API client as there is a missing or empty value for the Instellar API auth token. "+
-				"Set the auth_token value in the configuration or use the INSTELLAR_AUTH_TOKEN environment variable. "+
-				"If either is already set, ensure the value is not empty.",
-		)
-	}
+	settings := clientSettings(ctx, config, p.version, req.TerraformVersion, &resp.Diagnostics)
+	host, options := settings.Host, settings.Options
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	ctx = tflog.SetField(ctx, "instellar_host", host)
-	ctx = tflog.SetField(ctx, "instellar_auth_token", auth_token)
+	ctx = tflog.SetField(ctx, "instellar_auth_token", settings.AuthToken)
 	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, "instellar_auth_token")
 
 	tflog.Debug(ctx, "Creating Instellar client")
 
-	client, err := instc.NewClient(&host, &auth_token)
+	if apiclient.IsMemoryHost(host) {
+		tflog.Warn(ctx, "Using the in-memory Instellar backend, no resource is provisioned")
+	}
+
+	client, err := apiclient.New(host, settings.Tokens, options)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to create Instellar API Client",
@@ -129,12 +306,352 @@ func (p *instellarProvider) Configure(ctx context.Context, req provider.Configur
 		return
 	}
 
+	if options.ReadOnly {
+		tflog.Info(ctx, "Instellar provider is read only, resources will not be modified")
+	}
+
+	if boolValue(config.SkipCredentialsValidation, "INSTELLAR_SKIP_CREDENTIALS_VALIDATION") {
+		tflog.Debug(ctx, "Skipping Instellar credentials validation")
+	} else {
+		if err := client.Authenticate(ctx); err != nil {
+			addCredentialsError(err, host, settings.TokenAttribute, &resp.Diagnostics)
+			return
+		}
+
+		identity, err := client.WhoAmI(ctx)
+		if err != nil {
+			tflog.Debug(ctx, "Instellar API did not report the identity of the credentials", map[string]any{"error": err.Error()})
+		} else {
+			ctx = tflog.SetField(ctx, "instellar_account", identity.Data.Attributes.Account)
+			ctx = tflog.SetField(ctx, "instellar_organization", identity.Data.Attributes.Organization)
+		}
+	}
+
 	resp.DataSourceData = client
 	resp.ResourceData = client
 
 	tflog.Info(ctx, "Configured Instellar client", map[string]any{"success": true})
 }
 
+// userAgent identifies the provider build and the Terraform version making
+// API requests.
+func userAgent(version string, terraformVersion string, suffix string) string {
+	if terraformVersion == "" {
+		terraformVersion = "unknown"
+	}
+
+	agent := fmt.Sprintf("terraform-provider-instellar/%s (+https://registry.terraform.io/providers/upmaru/instellar) Terraform/%s", version, terraformVersion)
+
+	if suffix = strings.TrimSpace(suffix); suffix != "" {
+		agent += " " + suffix
+	}
+
+	return agent
+}
+
+// addCredentialsError reports why the credentials could not be validated,
+// pointing at the host attribute when the API could not be reached and at
+// the credential attribute when the API rejected it.
+func addCredentialsError(err error, host string, tokenAttribute path.Path, diags *diag.Diagnostics) {
+	var apiErr *apiclient.APIError
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+
+	switch {
+	case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden):
+		diags.AddAttributeError(
+			tokenAttribute,
+			"Invalid Instellar Credentials",
+			"The Instellar API at "+host+" rejected the configured credentials. "+
+				"Ensure the credential has not been revoked or expired and belongs to this host, "+
+				"or set skip_credentials_validation to plan without contacting the API.\n\n"+
+				"Instellar Client Error: "+err.Error(),
+		)
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		diags.AddAttributeError(
+			path.Root("host"),
+			"Unexpected Instellar API Host",
+			"The host "+host+" does not serve the Instellar API. Ensure host points at your Instellar installation.\n\n"+
+				"Instellar Client Error: "+err.Error(),
+		)
+	case errors.As(err, &dnsErr) || errors.As(err, &opErr) || errors.As(err, &certErr):
+		diags.AddAttributeError(
+			path.Root("host"),
+			"Unable to Reach Instellar API Host",
+			"The provider could not connect to "+host+". Ensure the host is correct and reachable from this machine, "+
+				"or set skip_credentials_validation to plan without contacting the API.\n\n"+
+				"Instellar Client Error: "+err.Error(),
+		)
+	default:
+		diags.AddError(
+			"Unable to Validate Instellar Credentials",
+			"An unexpected error occurred when validating the Instellar credentials. "+
+				"If the error is not clear, please contact provider developers.\n\n"+
+				"Instellar Client Error: "+err.Error(),
+		)
+	}
+}
+
+// clientCredentials returns a source of OAuth2 access tokens, ensuring the
+// client secret and token endpoint are set alongside the client id.
+func clientCredentials(config instellarProviderModel, clientID string, diags *diag.Diagnostics) apiclient.TokenSource {
+	clientSecret := stringValue(config.ClientSecret, "INSTELLAR_CLIENT_SECRET")
+	tokenURL := stringValue(config.TokenURL, "INSTELLAR_TOKEN_URL")
+
+	if clientSecret == "" {
+		diags.AddAttributeError(
+			path.Root("client_secret"),
+			"Missing Instellar OAuth2 Client Secret",
+			"The provider cannot create Instellar API client as client_id is set without a client secret. "+
+				"Set the client_secret value in the configuration or use the INSTELLAR_CLIENT_SECRET environment variable.",
+		)
+	}
+
+	if parsed, err := url.Parse(tokenURL); tokenURL == "" || err != nil || parsed.Host == "" {
+		diags.AddAttributeError(
+			path.Root("token_url"),
+			"Invalid Instellar OAuth2 Token URL",
+			"The provider cannot create Instellar API client as client_id is set without a valid token endpoint URL. "+
+				"Set the token_url value in the configuration or use the INSTELLAR_TOKEN_URL environment variable.",
+		)
+	}
+
+	return apiclient.ClientCredentials(clientID, clientSecret, tokenURL)
+}
+
+// tlsConfig builds the TLS settings for the API host from the configuration
+// and the matching INSTELLAR_* env variables.
+func tlsConfig(config instellarProviderModel, diags *diag.Diagnostics) *tls.Config {
+	caCertAttribute := path.Root("ca_cert_pem")
+	caCertPEM := stringValue(config.CACertPEM, "INSTELLAR_CA_CERT_PEM")
+
+	if caCertFile := stringValue(config.CACertFile, "INSTELLAR_CA_CERT_FILE"); caCertFile != "" && caCertPEM == "" {
+		caCertAttribute = path.Root("ca_cert_file")
+
+		contents, err := os.ReadFile(caCertFile)
+		if err != nil {
+			diags.AddAttributeError(
+				caCertAttribute,
+				"Unable to Read Instellar CA Certificate",
+				"The provider cannot create Instellar API client as the CA certificate file could not be read: "+err.Error(),
+			)
+			return nil
+		}
+
+		caCertPEM = string(contents)
+	}
+
+	insecureSkipVerify := boolValue(config.InsecureSkipVerify, "INSTELLAR_INSECURE_SKIP_VERIFY")
+
+	if insecureSkipVerify {
+		diags.AddAttributeWarning(
+			path.Root("insecure_skip_verify"),
+			"Instellar TLS Certificate Verification Disabled",
+			"The provider will not verify the TLS certificate of the Instellar API host. "+
+				"Any machine able to intercept the connection can read the auth token and every secret sent to the API. "+
+				"Only use insecure_skip_verify for testing and use ca_cert_pem or ca_cert_file to trust a private CA instead.",
+		)
+	}
+
+	tlsOptions := apiclient.TLSOptions{
+		CACertPEM:          caCertPEM,
+		ClientCertPEM:      stringValue(config.ClientCertPEM, "INSTELLAR_CLIENT_CERT_PEM"),
+		ClientKeyPEM:       stringValue(config.ClientKeyPEM, "INSTELLAR_CLIENT_KEY_PEM"),
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+
+	clientTLS, err := tlsOptions.Config()
+
+	switch {
+	case errors.Is(err, apiclient.ErrInvalidCACert):
+		diags.AddAttributeError(
+			caCertAttribute,
+			"Invalid Instellar CA Certificate",
+			"The provider cannot create Instellar API client as the CA certificate does not contain a PEM encoded certificate.",
+		)
+	case err != nil:
+		diags.AddAttributeError(
+			path.Root("client_cert_pem"),
+			"Invalid Instellar Client Certificate",
+			"The provider cannot create Instellar API client as the client certificate and key could not be loaded: "+err.Error(),
+		)
+	}
+
+	return clientTLS
+}
+
+// stringValue returns the configured value of an attribute, falling back to
+// the given env variable when the attribute is not set.
+func stringValue(value types.String, env string) string {
+	if value.IsNull() {
+		return os.Getenv(env)
+	}
+
+	return value.ValueString()
+}
+
+// ClientSettings are everything needed to create a client, resolved from the
+// provider configuration, env variables and the credentials file.
+type ClientSettings struct {
+	Host      string
+	AuthToken string
+	Tokens    apiclient.TokenSource
+	// TokenAttribute is the attribute providing the credential, errors about
+	// the credential are reported on it.
+	TokenAttribute path.Path
+	Options        apiclient.Options
+}
+
+// EnvironmentSettings resolves the client settings of an empty provider
+// block, from env variables and the credentials file alone. It lets tools
+// running outside of Terraform reach the API the way the provider would.
+func EnvironmentSettings(ctx context.Context, version string) (ClientSettings, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	settings := clientSettings(ctx, instellarProviderModel{}, version, "", &diags)
+
+	return settings, diags
+}
+
+func clientSettings(ctx context.Context, config instellarProviderModel, version string, terraformVersion string, diags *diag.Diagnostics) ClientSettings {
+	host, auth_token := resolveCredentials(config, diags)
+
+	var tokens apiclient.TokenSource = apiclient.StaticToken(auth_token)
+
+	tokenAttribute := path.Root("auth_token")
+	clientID := stringValue(config.ClientID, "INSTELLAR_CLIENT_ID")
+
+	switch {
+	case !config.AuthTokenCommand.IsNull():
+		var command []string
+
+		d := config.AuthTokenCommand.ElementsAs(ctx, &command, false)
+		diags.Append(d...)
+
+		tokens = apiclient.CommandToken(command)
+		tokenAttribute = path.Root("auth_token_command")
+	case clientID != "" && config.AuthToken.IsNull():
+		tokens = clientCredentials(config, clientID, diags)
+		tokenAttribute = path.Root("client_id")
+	}
+
+	if auth_token == "" && config.AuthTokenCommand.IsNull() && clientID == "" && !apiclient.IsMemoryHost(host) {
+		diags.AddAttributeError(
+			path.Root("auth_token"),
+			"Missing Instellar API Auth Token",
+			"The provider cannot create Instellar API client as there is a missing or empty value for the Instellar API auth token. "+
+				"Set the auth_token, auth_token_command or client_id value in the configuration, use the INSTELLAR_AUTH_TOKEN environment variable or select a profile. "+
+				"If either is already set, ensure the value is not empty.",
+		)
+	}
+
+	options := apiclient.Options{
+		MaxRetries:     apiclient.DefaultMaxRetries,
+		RetryMaxWait:   durationValue(config.RetryMaxWait, apiclient.DefaultRetryMaxWait, path.Root("retry_max_wait"), diags),
+		RequestTimeout: durationValue(config.RequestTimeout, apiclient.DefaultRequestTimeout, path.Root("request_timeout"), diags),
+		CacheTTL:       apiclient.DefaultCacheTTL,
+	}
+
+	if !config.MaxRetries.IsNull() {
+		options.MaxRetries = int(config.MaxRetries.ValueInt64())
+	}
+
+	options.MaxConcurrentRequests = int(config.MaxConcurrentRequests.ValueInt64())
+	options.RequestsPerSecond = int(config.RequestsPerSecond.ValueInt64())
+
+	options.TLSConfig = tlsConfig(config, diags)
+
+	if value := stringValue(config.ProxyURL, "INSTELLAR_PROXY_URL"); value != "" {
+		proxyURL, err := url.Parse(value)
+
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			diags.AddAttributeError(
+				path.Root("proxy_url"),
+				"Invalid Instellar Proxy URL",
+				"The provider cannot create Instellar API client as proxy_url is not a valid URL. "+
+					"Use a value such as \"http://proxy.internal:3128\".",
+			)
+		}
+
+		options.ProxyURL = proxyURL
+	}
+
+	if !config.Headers.IsNull() {
+		d := config.Headers.ElementsAs(ctx, &options.Headers, false)
+		diags.Append(d...)
+	}
+
+	options.UserAgent = userAgent(version, terraformVersion, stringValue(config.UserAgentSuffix, "INSTELLAR_USER_AGENT_SUFFIX"))
+	options.ReadOnly = boolValue(config.ReadOnly, "INSTELLAR_READ_ONLY")
+	options.Organization = stringValue(config.Organization, "INSTELLAR_ORGANIZATION")
+	options.Defaults = providerDefaults(ctx, config, diags)
+
+	return ClientSettings{
+		Host:           host,
+		AuthToken:      auth_token,
+		Tokens:         tokens,
+		TokenAttribute: tokenAttribute,
+		Options:        options,
+	}
+}
+
+// providerDefaults reads the defaults block, a block left out of the
+// configuration has no defaults.
+func providerDefaults(ctx context.Context, config instellarProviderModel, diags *diag.Diagnostics) apiclient.Defaults {
+	if config.Defaults.IsNull() || config.Defaults.IsUnknown() {
+		return apiclient.Defaults{}
+	}
+
+	var block instellarDefaultsModel
+
+	diags.Append(config.Defaults.As(ctx, &block, basetypes.ObjectAsOptions{})...)
+
+	defaults := apiclient.Defaults{
+		ProviderName: block.ProviderName.ValueString(),
+		Region:       block.Region.ValueString(),
+	}
+
+	if !block.Channels.IsNull() && !block.Channels.IsUnknown() {
+		defaults.Channels = []string{}
+		diags.Append(block.Channels.ElementsAs(ctx, &defaults.Channels, false)...)
+	}
+
+	return defaults
+}
+
+// boolValue returns the configured value of a boolean attribute, falling back
+// to the given env variable when the attribute is not set.
+func boolValue(value types.Bool, env string) bool {
+	if value.IsNull() {
+		enabled, _ := strconv.ParseBool(os.Getenv(env))
+		return enabled
+	}
+
+	return value.ValueBool()
+}
+
+// durationValue parses an optional duration attribute such as "30s", falling
+// back to the given default when the attribute is not set.
+func durationValue(value types.String, fallback time.Duration, attribute path.Path, diags *diag.Diagnostics) time.Duration {
+	if value.IsNull() || value.IsUnknown() {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value.ValueString())
+
+	if err != nil || duration < 0 {
+		diags.AddAttributeError(
+			attribute,
+			"Invalid Instellar Provider Duration",
+			"The provider cannot create Instellar API client as "+attribute.String()+" is not a valid duration. "+
+				"Use a value such as \"30s\" or \"2m\".",
+		)
+	}
+
+	return duration
+}
+
 func (p *instellarProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		uplink.NewUplinkDataSource,
@@ -1 +1,369 @@
 package instellar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+)
+
+const testCredentialsFile = `
+[default]
+host = "https://default.instellar.test"
+auth_token = "default-token"
+
+[staging]
+host = "https://staging.instellar.test"
+auth_token = "staging-token"
+`
+
+// setupCredentialsEnv clears the env variables read by the provider and points
+// the credentials file at a temporary copy of testCredentialsFile.
+func setupCredentialsEnv(t *testing.T) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "credentials")
+
+	if err := os.WriteFile(file, []byte(testCredentialsFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, env := range []string{"INSTELLAR_HOST", "INSTELLAR_AUTH_TOKEN", "INSTELLAR_CLIENT_ID", "INSTELLAR_PROFILE"} {
+		t.Setenv(env, "")
+	}
+
+	t.Setenv("INSTELLAR_CREDENTIALS_FILE", file)
+
+	return file
+}
+
+func assertCredentials(t *testing.T, config instellarProviderModel, expectedHost string, expectedToken string) {
+	t.Helper()
+
+	var diags diag.Diagnostics
+
+	host, authToken := resolveCredentials(config, &diags)
+
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if host != expectedHost || authToken != expectedToken {
+		t.Errorf("expected %s / %s, got %s / %s", expectedHost, expectedToken, host, authToken)
+	}
+}
+
+func TestResolveCredentialsPrefersConfiguration(t *testing.T) {
+	setupCredentialsEnv(t)
+	t.Setenv("INSTELLAR_HOST", "https://env.instellar.test")
+	t.Setenv("INSTELLAR_AUTH_TOKEN", "env-token")
+
+	config := instellarProviderModel{
+		Host:      types.StringValue("https://config.instellar.test"),
+		AuthToken: types.StringValue("config-token"),
+	}
+
+	assertCredentials(t, config, "https://config.instellar.test", "config-token")
+}
+
+func TestResolveCredentialsDoesNotMixSources(t *testing.T) {
+	setupCredentialsEnv(t)
+
+	t.Setenv("INSTELLAR_HOST", "https://env.instellar.test")
+	assertCredentials(t, instellarProviderModel{}, "https://env.instellar.test", "")
+
+	t.Setenv("INSTELLAR_HOST", "")
+	t.Setenv("INSTELLAR_AUTH_TOKEN", "env-token")
+	assertCredentials(t, instellarProviderModel{}, defaultHost, "env-token")
+
+	t.Setenv("INSTELLAR_AUTH_TOKEN", "")
+	config := instellarProviderModel{Host: types.StringValue("https://config.instellar.test")}
+	assertCredentials(t, config, "https://config.instellar.test", "")
+}
+
+func TestResolveCredentialsSelectsProfile(t *testing.T) {
+	setupCredentialsEnv(t)
+
+	assertCredentials(t, instellarProviderModel{}, "https://default.instellar.test", "default-token")
+
+	t.Setenv("INSTELLAR_PROFILE", "staging")
+	assertCredentials(t, instellarProviderModel{}, "https://staging.instellar.test", "staging-token")
+
+	config := instellarProviderModel{Profile: types.StringValue("default")}
+	assertCredentials(t, config, "https://default.instellar.test", "default-token")
+}
+
+func TestResolveCredentialsWithoutCredentialsFile(t *testing.T) {
+	setupCredentialsEnv(t)
+	t.Setenv("INSTELLAR_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("INSTELLAR_AUTH_TOKEN", "env-token")
+
+	assertCredentials(t, instellarProviderModel{}, defaultHost, "env-token")
+}
+
+func TestResolveCredentialsReportsUnknownProfile(t *testing.T) {
+	setupCredentialsEnv(t)
+
+	var diags diag.Diagnostics
+
+	resolveCredentials(instellarProviderModel{Profile: types.StringValue("production")}, &diags)
+
+	if !diags.HasError() {
+		t.Fatalf("expected unknown profile error")
+	}
+
+	if attributeDiag, ok := diags.Errors()[0].(diag.DiagnosticWithPath); !ok || !attributeDiag.Path().Equal(path.Root("profile")) {
+		t.Errorf("expected error on profile attribute, got %v", diags.Errors()[0])
+	}
+}
+
+func TestResolveCredentialsRejectsNamedProfileWithOtherCredentials(t *testing.T) {
+	testCases := map[string]struct {
+		env    map[string]string
+		config instellarProviderModel
+	}{
+		"configured profile and env token": {
+			env:    map[string]string{"INSTELLAR_AUTH_TOKEN": "env-token"},
+			config: instellarProviderModel{Profile: types.StringValue("staging")},
+		},
+		"env profile and configured host": {
+			env:    map[string]string{"INSTELLAR_PROFILE": "staging"},
+			config: instellarProviderModel{Host: types.StringValue("https://config.instellar.test")},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			setupCredentialsEnv(t)
+
+			for env, value := range testCase.env {
+				t.Setenv(env, value)
+			}
+
+			var diags diag.Diagnostics
+
+			resolveCredentials(testCase.config, &diags)
+
+			if !diags.HasError() {
+				t.Fatalf("expected conflicting credentials error")
+			}
+
+			if attributeDiag, ok := diags.Errors()[0].(diag.DiagnosticWithPath); !ok || !attributeDiag.Path().Equal(path.Root("profile")) {
+				t.Errorf("expected error on profile attribute, got %v", diags.Errors()[0])
+			}
+		})
+	}
+}
+
+func TestResolveCredentialsReadsINIProfiles(t *testing.T) {
+	file := setupCredentialsEnv(t)
+
+	document := `
+; written by hand
+[default]
+host = https://default.instellar.test
+auth_token = default-token
+
+[staging]
+host = "https://staging.instellar.test"
+auth_token = staging-token
+`
+
+	if err := os.WriteFile(file, []byte(document), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	assertCredentials(t, instellarProviderModel{}, "https://default.instellar.test", "default-token")
+	assertCredentials(t, instellarProviderModel{Profile: types.StringValue("staging")}, "https://staging.instellar.test", "staging-token")
+}
+
+func TestResolveCredentialsReportsUnreadableCredentialsFile(t *testing.T) {
+	file := setupCredentialsEnv(t)
+
+	if err := os.WriteFile(file, []byte("host = https://orphan.instellar.test\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var diags diag.Diagnostics
+
+	resolveCredentials(instellarProviderModel{}, &diags)
+
+	if attributeDiag, ok := diags.Errors()[0].(diag.DiagnosticWithPath); !diags.HasError() || !ok || !attributeDiag.Path().Equal(path.Root("credentials_file")) {
+		t.Errorf("expected error on credentials_file attribute, got %v", diags)
+	}
+}
+
+func TestAddCredentialsErrorPointsAtAttribute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	unreachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unreachable.Close()
+
+	cases := map[string]path.Path{
+		server.URL:      path.Root("auth_token"),
+		unreachable.URL: path.Root("host"),
+	}
+
+	for host, expected := range cases {
+		client, err := apiclient.New(host, apiclient.StaticToken("auth-token"), apiclient.Options{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		err = client.Authenticate(context.Background())
+
+		var diags diag.Diagnostics
+
+		addCredentialsError(err, host, path.Root("auth_token"), &diags)
+
+		if attributeDiag, ok := diags.Errors()[0].(diag.DiagnosticWithPath); !ok || !attributeDiag.Path().Equal(expected) {
+			t.Errorf("expected error on %s for %s, got %v", expected, host, diags.Errors()[0])
+		}
+	}
+}
+
+func TestConfigureDoesNotRequireIdentity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/provision/automation/callback" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"data":{"token":"session-token"}}`))
+	}))
+	defer server.Close()
+
+	setupCredentialsEnv(t)
+
+	ctx := context.Background()
+
+	protocol6, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	schema, err := protocol6.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	objectType, ok := schema.Provider.ValueType().(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected provider schema type %T", schema.Provider.ValueType())
+	}
+
+	values := map[string]tftypes.Value{}
+
+	for name, attributeType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	values["host"] = tftypes.NewValue(tftypes.String, server.URL)
+	values["auth_token"] = tftypes.NewValue(tftypes.String, "auth-token")
+
+	config, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, values))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resp, err := protocol6.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{TerraformVersion: "1.8.0", Config: &config})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, diagnostic := range resp.Diagnostics {
+		t.Errorf("unexpected diagnostic: %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+}
+
+func TestClientSettingsReadsProxyURLFromEnv(t *testing.T) {
+	setupCredentialsEnv(t)
+	t.Setenv("INSTELLAR_AUTH_TOKEN", "env-token")
+	t.Setenv("INSTELLAR_PROXY_URL", "http://env-proxy.internal:3128")
+
+	testCases := map[string]struct {
+		config   types.String
+		expected string
+	}{
+		"env":           {config: types.StringNull(), expected: "http://env-proxy.internal:3128"},
+		"configuration": {config: types.StringValue("http://config-proxy.internal:3128"), expected: "http://config-proxy.internal:3128"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var diags diag.Diagnostics
+
+			settings := clientSettings(context.Background(), instellarProviderModel{ProxyURL: testCase.config}, "test", "", &diags)
+
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+
+			if settings.Options.ProxyURL == nil || settings.Options.ProxyURL.String() != testCase.expected {
+				t.Errorf("expected proxy %s, got %v", testCase.expected, settings.Options.ProxyURL)
+			}
+		})
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	expected := "terraform-provider-instellar/1.2.3 (+https://registry.terraform.io/providers/upmaru/instellar) Terraform/1.6.0"
+
+	if agent := userAgent("1.2.3", "1.6.0", ""); agent != expected {
+		t.Errorf("expected %q, got %q", expected, agent)
+	}
+
+	if agent := userAgent("1.2.3", "1.6.0", "pipeline/nightly"); agent != expected+" pipeline/nightly" {
+		t.Errorf("expected suffix to be appended, got %q", agent)
+	}
+}
+
+func TestProviderSchemaIsServedOverBothProtocols(t *testing.T) {
+	protocol5, err := providerserver.NewProtocol5WithError(New("test")())()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	schema5, err := protocol5.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, diagnostic := range schema5.Diagnostics {
+		t.Errorf("unexpected protocol 5 diagnostic: %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	protocol6, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	schema6, err := protocol6.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, diagnostic := range schema6.Diagnostics {
+		t.Errorf("unexpected protocol 6 diagnostic: %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	if len(schema5.ResourceSchemas) != len(schema6.ResourceSchemas) || len(schema5.ResourceSchemas) == 0 {
+		t.Errorf("expected the same resources over both protocols, got %d and %d", len(schema5.ResourceSchemas), len(schema6.ResourceSchemas))
+	}
+}
@@ -0,0 +1,190 @@
+package instellar_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/upmaru/terraform-provider-instellar/instellar"
+	"github.com/upmaru/terraform-provider-instellar/internal/acceptancetest"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+)
+
+var credentialType = tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+	"username":    tftypes.String,
+	"password":    tftypes.String,
+	"resource":    tftypes.String,
+	"host":        tftypes.String,
+	"port":        tftypes.Number,
+	"certificate": tftypes.String,
+	"secure":      tftypes.Bool,
+}}
+
+// created holds, for every resource, the values it is created from and the
+// states the API reports for it from creation on, as recorded in the
+// fixtures of the instellar-go client.
+var created = map[string]struct {
+	values map[string]tftypes.Value
+	states []string
+}{
+	"instellar_cluster": {
+		values: map[string]tftypes.Value{
+			"name":           tftypes.NewValue(tftypes.String, "pizza"),
+			"provider_name":  tftypes.NewValue(tftypes.String, "aws"),
+			"region":         tftypes.NewValue(tftypes.String, "ap-southeast-1"),
+			"endpoint":       tftypes.NewValue(tftypes.String, "127.0.0.1:8443"),
+			"password_token": tftypes.NewValue(tftypes.String, "some-password-or-token"),
+		},
+		states: []string{"connecting", "syncing", "healthy"},
+	},
+	"instellar_node": {
+		values: map[string]tftypes.Value{
+			"slug":       tftypes.NewValue(tftypes.String, "pizza-node-01"),
+			"public_ip":  tftypes.NewValue(tftypes.String, "10.0.0.1"),
+			"cluster_id": tftypes.NewValue(tftypes.String, "1"),
+		},
+		states: []string{"created", "healthy"},
+	},
+	"instellar_uplink": {
+		values: map[string]tftypes.Value{
+			"channel_slug": tftypes.NewValue(tftypes.String, "develop"),
+			"kit_slug":     tftypes.NewValue(tftypes.String, "pro"),
+			"cluster_id":   tftypes.NewValue(tftypes.String, "1"),
+		},
+		states: []string{"created", "active"},
+	},
+	"instellar_balancer": {
+		values: map[string]tftypes.Value{
+			"name":       tftypes.NewValue(tftypes.String, "pizza"),
+			"address":    tftypes.NewValue(tftypes.String, "pizza.example.com"),
+			"cluster_id": tftypes.NewValue(tftypes.String, "1"),
+		},
+		states: []string{"active"},
+	},
+	"instellar_component": {
+		values: map[string]tftypes.Value{
+			"name":           tftypes.NewValue(tftypes.String, "some-db"),
+			"provider_name":  tftypes.NewValue(tftypes.String, "aws"),
+			"driver":         tftypes.NewValue(tftypes.String, "database/postgresql"),
+			"driver_version": tftypes.NewValue(tftypes.String, "15"),
+			"cluster_ids":    tftypes.NewValue(tftypes.List{ElementType: tftypes.Number}, []tftypes.Value{tftypes.NewValue(tftypes.Number, 1)}),
+			"channels":       tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "develop")}),
+			"credential": tftypes.NewValue(credentialType, map[string]tftypes.Value{
+				"username":    tftypes.NewValue(tftypes.String, "postgres"),
+				"password":    tftypes.NewValue(tftypes.String, "postgres"),
+				"resource":    tftypes.NewValue(tftypes.String, "some-db"),
+				"host":        tftypes.NewValue(tftypes.String, "localhost"),
+				"port":        tftypes.NewValue(tftypes.Number, 5432),
+				"certificate": tftypes.NewValue(tftypes.String, nil),
+				"secure":      tftypes.NewValue(tftypes.Bool, nil),
+			}),
+		},
+		states: []string{"active"},
+	},
+	"instellar_storage": {
+		values: map[string]tftypes.Value{
+			"host":              tftypes.NewValue(tftypes.String, "s3.amazonaws.com"),
+			"bucket":            tftypes.NewValue(tftypes.String, "pizza"),
+			"region":            tftypes.NewValue(tftypes.String, "ap-southeast-1"),
+			"access_key_id":     tftypes.NewValue(tftypes.String, "access-key"),
+			"secret_access_key": tftypes.NewValue(tftypes.String, "secret-key"),
+		},
+		states: []string{"syncing", "healthy"},
+	},
+}
+
+// forEachResource runs test on every resource of the provider, in a subtest
+// named after its type.
+func forEachResource(t *testing.T, test func(t *testing.T, typeName string, r resource.Resource)) {
+	ctx := context.Background()
+
+	for _, newResource := range instellar.New("test")().Resources(ctx) {
+		r := newResource()
+
+		metadata := &resource.MetadataResponse{}
+		r.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "instellar"}, metadata)
+
+		t.Run(metadata.TypeName, func(t *testing.T) {
+			test(t, metadata.TypeName, r)
+		})
+	}
+}
+
+func TestResourcesReadRemovesDeletedObjects(t *testing.T) {
+	forEachResource(t, func(t *testing.T, _ string, r resource.Resource) {
+		resp := acceptancetest.ReadDeleted(t, r, "1")
+
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected error: %v", resp.Diagnostics)
+		}
+
+		if !resp.State.Raw.IsNull() {
+			t.Errorf("expected deleted object to be removed from state, got %s", resp.State.Raw)
+		}
+	})
+}
+
+func TestResourcesCreateWaitsForReadyState(t *testing.T) {
+	forEachResource(t, func(t *testing.T, typeName string, r resource.Resource) {
+		t.Parallel()
+
+		testCase, ok := created[typeName]
+		if !ok {
+			t.Fatalf("no values to create %s from", typeName)
+		}
+
+		var reads int32
+
+		client := acceptancetest.ServerClient(t, apiclient.Options{}, func(w http.ResponseWriter, req *http.Request) {
+			state := testCase.states[0]
+
+			if req.Method == http.MethodGet {
+				read := int(atomic.AddInt32(&reads, 1))
+				state = testCase.states[len(testCase.states)-1]
+
+				if read < len(testCase.states) {
+					state = testCase.states[read]
+				}
+			} else {
+				w.WriteHeader(http.StatusCreated)
+			}
+
+			_, _ = fmt.Fprintf(w, `{"data":{"attributes":{"id":1,"slug":"pizza","cluster_id":1,"current_state":%q}}}`, state)
+		})
+
+		resp := acceptancetest.Create(t, r, client, testCase.values)
+
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected error: %v", resp.Diagnostics)
+		}
+
+		var currentState types.String
+
+		if diags := resp.State.GetAttribute(context.Background(), path.Root("current_state"), &currentState); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+
+		ready := testCase.states[len(testCase.states)-1]
+
+		if currentState.ValueString() != ready {
+			t.Errorf("expected Create to wait for %s, got %s", ready, currentState)
+		}
+
+		// Objects created ready are read once.
+		expected := len(testCase.states) - 1
+
+		if expected == 0 {
+			expected = 1
+		}
+
+		if int(atomic.LoadInt32(&reads)) != expected {
+			t.Errorf("expected %d reads, got %d", expected, atomic.LoadInt32(&reads))
+		}
+	})
+}
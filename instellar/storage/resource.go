@@ -4,17 +4,24 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"time"
 
 	// instellar client = instc.
 	instc "github.com/upmaru/instellar-go"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+	"github.com/upmaru/terraform-provider-instellar/internal/operation"
+	"github.com/upmaru/terraform-provider-instellar/internal/organization"
+	"github.com/upmaru/terraform-provider-instellar/internal/timestamps"
+	"github.com/upmaru/terraform-provider-instellar/internal/wait"
 )
 
 var (
@@ -23,31 +30,53 @@ var (
 	_ resource.ResourceWithImportState = &storageResource{}
 )
 
+// states are the states Create tells apart while waiting for a storage, the
+// API moves it from syncing to healthy.
+var states = wait.States{Ready: []string{"healthy"}, Failed: wait.FailedStates}
+
+// apiAttributes maps the fields of API validation errors to the attributes
+// they come from.
+var apiAttributes = map[string]path.Path{
+	"host":                         path.Root("host"),
+	"bucket":                       path.Root("bucket"),
+	"region":                       path.Root("region"),
+	"credential_access_key_id":     path.Root("access_key_id"),
+	"credential_secret_access_key": path.Root("secret_access_key"),
+	"insterra_component_id":        path.Root("insterra_component_id"),
+}
+
+// kind describes storage to the steps every resource shares.
+var kind = operation.Kind{Name: "storage", Attributes: apiAttributes, States: states}
+
 func NewStorageResource() resource.Resource {
 	return &storageResource{}
 }
 
 type storageResource struct {
-	client *instc.Client
+	client *apiclient.Client
 }
 
 type storageResourceModel struct {
-	ID                  types.String `tfsdk:"id"`
-	CurrentState        types.String `tfsdk:"current_state"`
-	Host                types.String `tfsdk:"host"`
-	Bucket              types.String `tfsdk:"bucket"`
-	Region              types.String `tfsdk:"region"`
-	AccessKeyID         types.String `tfsdk:"access_key_id"`
-	SecretAccessKey     types.String `tfsdk:"secret_access_key"`
-	InsterraComponentID types.Int64  `tfsdk:"insterra_component_id"`
-	LastUpdated         types.String `tfsdk:"last_updated"`
+	ID                  types.String   `tfsdk:"id"`
+	CurrentState        types.String   `tfsdk:"current_state"`
+	Host                types.String   `tfsdk:"host"`
+	Bucket              types.String   `tfsdk:"bucket"`
+	Region              types.String   `tfsdk:"region"`
+	AccessKeyID         types.String   `tfsdk:"access_key_id"`
+	SecretAccessKey     types.String   `tfsdk:"secret_access_key"`
+	InsterraComponentID types.Int64    `tfsdk:"insterra_component_id"`
+	Organization        types.String   `tfsdk:"organization"`
+	Timeouts            timeouts.Value `tfsdk:"timeouts"`
+	CreatedAt           types.String   `tfsdk:"created_at"`
+	UpdatedAt           types.String   `tfsdk:"updated_at"`
+	LastUpdated         types.String   `tfsdk:"last_updated"`
 }
 
 func (r *storageResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_storage"
 }
 
-func (r *storageResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *storageResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Storage is what instellar use to store all the build artifacts / ssl certificates / others. Basically anything that's needed to manage your deployments.",
 		Attributes: map[string]schema.Attribute{
@@ -86,11 +115,19 @@ func (r *storageResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 			"insterra_component_id": schema.Int64Attribute{
 				Description: "Reference to insterra component",
 				Optional:    true,
-			},
-			"last_updated": schema.StringAttribute{
-				Description: "Timesmap of teraform update",
 				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+					int64planmodifier.RequiresReplaceIfConfigured(),
+				},
 			},
+			"organization": organization.ResourceAttribute(),
+			"created_at":   timestamps.CreatedAtAttribute(),
+			"updated_at":   timestamps.UpdatedAtAttribute(),
+			"last_updated": timestamps.LastUpdatedAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{Create: true, Update: true, Delete: true}),
 		},
 	}
 }
@@ -100,13 +137,13 @@ func (r *storageResource) Configure(_ context.Context, req resource.ConfigureReq
 		return
 	}
 
-	client, ok := req.ProviderData.(*instc.Client)
+	client, ok := req.ProviderData.(*apiclient.Client)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
 			fmt.Sprintf(
-				"Expected *instc.Client, got: %T. Please report this issue to the provider developers.",
+				"Expected *apiclient.Client, got: %T. Please report this issue to the provider developers.",
 				req.ProviderData,
 			),
 		)
@@ -126,6 +163,13 @@ func (r *storageResource) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Create, plan.Timeouts.Create, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	storageParams := instc.StorageParams{
 		Host:                      plan.Host.ValueString(),
 		Bucket:                    plan.Bucket.ValueString(),
@@ -135,16 +179,9 @@ func (r *storageResource) Create(ctx context.Context, req resource.CreateRequest
 		InsterraComponentID:       int(plan.InsterraComponentID.ValueInt64()),
 	}
 
-	storage, err := r.client.CreateStorage(storageParams)
+	storage, err := r.client.WithContext(ctx).CreateStorage(storageParams)
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error creating storage",
-			fmt.Sprintf(
-				"Error creating storage: %s",
-				err.Error(),
-			),
-		)
+	if kind.Failed(&resp.Diagnostics, operation.Create, err) {
 		return
 	}
 
@@ -155,7 +192,33 @@ func (r *storageResource) Create(ctx context.Context, req resource.CreateRequest
 	plan.Region = types.StringValue(storage.Data.Attributes.Region)
 	plan.AccessKeyID = types.StringValue(storage.Data.Attributes.CredentialAccessKeyID)
 	plan.SecretAccessKey = types.StringValue(storage.Data.Attributes.CredentialSecretAccessKey)
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	var (
+		reported            apiclient.Reported
+		insterraComponentID int
+	)
+
+	read := func(ctx context.Context) (string, error) {
+		storage, err := r.client.GetStorage(apiclient.WithoutCache(ctx), plan.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		reported = storage.Reported
+		insterraComponentID = storage.InsterraComponentID
+
+		return storage.Data.Attributes.CurrentState, nil
+	}
+
+	kind.AwaitReady(ctx, &resp.Diagnostics, plan.ID.ValueString(), read, &plan.CurrentState)
+
+	if insterraComponentID != 0 {
+		plan.InsterraComponentID = types.Int64Value(int64(insterraComponentID))
+	} else if plan.InsterraComponentID.IsUnknown() {
+		plan.InsterraComponentID = types.Int64Null()
+	}
+
+	timestamps.Set(ctx, reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -173,12 +236,11 @@ func (r *storageResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
-	storage, err := r.client.GetStorage(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading storage",
-			"cloud not read storage id "+state.ID.ValueString()+": "+err.Error(),
-		)
+	ctx = apiclient.WithOrganization(ctx, state.Organization.ValueString())
+
+	storage, err := r.client.GetStorage(ctx, state.ID.ValueString())
+
+	if kind.Refresh(ctx, resp, state.ID.ValueString(), err) {
 		return
 	}
 
@@ -189,6 +251,12 @@ func (r *storageResource) Read(ctx context.Context, req resource.ReadRequest, re
 	state.SecretAccessKey = types.StringValue(storage.Data.Attributes.CredentialSecretAccessKey)
 	state.CurrentState = types.StringValue(storage.Data.Attributes.CurrentState)
 
+	if storage.InsterraComponentID != 0 {
+		state.InsterraComponentID = types.Int64Value(int64(storage.InsterraComponentID))
+	}
+
+	timestamps.Refresh(ctx, storage.Reported, &state.CreatedAt, &state.UpdatedAt, &state.LastUpdated)
+
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -204,6 +272,13 @@ func (r *storageResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Update, plan.Timeouts.Update, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	storageParams := instc.StorageParams{
 		Host:                      plan.Host.ValueString(),
 		Bucket:                    plan.Bucket.ValueString(),
@@ -212,26 +287,15 @@ func (r *storageResource) Update(ctx context.Context, req resource.UpdateRequest
 		CredentialSecretAccessKey: plan.SecretAccessKey.ValueString(),
 	}
 
-	_, err := r.client.UpdateStorage(plan.ID.ValueString(), storageParams)
+	_, err := r.client.WithContext(ctx).UpdateStorage(plan.ID.ValueString(), storageParams)
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error updating storage",
-			fmt.Sprintf(
-				"Error updating storage: %s",
-				err.Error(),
-			),
-		)
+	if kind.Failed(&resp.Diagnostics, operation.Update, err) {
 		return
 	}
 
-	storage, err := r.client.GetStorage(plan.ID.ValueString())
+	storage, err := r.client.GetStorage(ctx, plan.ID.ValueString())
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading storage",
-			"Could not read storage ID "+plan.ID.ValueString()+": "+err.Error(),
-		)
+	if kind.ReadFailed(&resp.Diagnostics, plan.ID.ValueString(), err) {
 		return
 	}
 
@@ -241,7 +305,14 @@ func (r *storageResource) Update(ctx context.Context, req resource.UpdateRequest
 	plan.AccessKeyID = types.StringValue(storage.Data.Attributes.CredentialAccessKeyID)
 	plan.SecretAccessKey = types.StringValue(storage.Data.Attributes.CredentialSecretAccessKey)
 	plan.CurrentState = types.StringValue(storage.Data.Attributes.CurrentState)
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	if storage.InsterraComponentID != 0 {
+		plan.InsterraComponentID = types.Int64Value(int64(storage.InsterraComponentID))
+	} else if plan.InsterraComponentID.IsUnknown() {
+		plan.InsterraComponentID = types.Int64Null()
+	}
+
+	timestamps.Set(ctx, storage.Reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -258,20 +329,33 @@ func (r *storageResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
-	_, err := r.client.DeleteStorage(state.ID.ValueString())
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Delete, state.Timeouts.Delete, state.Organization)
+	defer cancel()
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error deleting storage",
-			fmt.Sprintf(
-				"Error deleting storage: %s",
-				err.Error(),
-			),
-		)
+	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	_, err := r.client.WithContext(ctx).DeleteStorage(state.ID.ValueString())
+
+	if apiclient.IsNotFound(err) {
+		return
+	}
+
+	if kind.Failed(&resp.Diagnostics, operation.Delete, err) {
+		return
+	}
+
+	kind.AwaitDeletion(ctx, &resp.Diagnostics, state.ID.ValueString(), func(ctx context.Context) (string, error) {
+		storage, err := r.client.GetStorage(apiclient.WithoutCache(ctx), state.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		return storage.Data.Attributes.CurrentState, nil
+	})
 }
 
 func (r *storageResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	organization.ImportState(ctx, req, resp)
 }
@@ -3,35 +3,119 @@ package storage_test
 import (
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/upmaru/terraform-provider-instellar/internal/acceptance"
+	"github.com/upmaru/terraform-provider-instellar/internal/acceptancetest"
 )
 
 func TestAccStorageResource(t *testing.T) {
-	resource.ParallelTest(t, resource.TestCase{
-		ProtoV6ProviderFactories: acceptance.TestAccProtoV6ProviderFactories,
+	acceptance.ParallelTest(t, resource.TestCase{
 		Steps: []resource.TestStep{
 			{
 				Config: buildConfig(),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("instellar_storage.test", "host", "s3.amazonaws.com"),
 					// Verify computed attribute fields.
-					resource.TestCheckResourceAttr("instellar_storage.test", "current_state", "syncing"),
+					resource.TestCheckResourceAttr("instellar_storage.test", "current_state", "healthy"),
 					// Dynamic values
 					resource.TestCheckResourceAttrSet("instellar_storage.test", "id"),
-					resource.TestCheckResourceAttrSet("instellar_storage.test", "last_updated"),
+					resource.TestCheckResourceAttrSet("instellar_storage.test", "created_at"),
+					resource.TestCheckResourceAttrSet("instellar_storage.test", "updated_at"),
+					resource.TestCheckResourceAttrPair("instellar_storage.test", "last_updated", "instellar_storage.test", "updated_at"),
 				),
 			},
 			{
 				ResourceName:            "instellar_storage.test",
 				ImportState:             true,
 				ImportStateVerify:       true,
-				ImportStateVerifyIgnore: []string{"last_updated", "current_state", "insterra_component_id"},
+				ImportStateVerifyIgnore: []string{"current_state", "insterra_component_id"},
 			},
 		},
 	})
 }
 
+func TestStorageResourcePlanReplacesStorage(t *testing.T) {
+	prior := map[string]tftypes.Value{
+		"id":                    tftypes.NewValue(tftypes.String, "1"),
+		"current_state":         tftypes.NewValue(tftypes.String, "active"),
+		"host":                  tftypes.NewValue(tftypes.String, "s3.amazonaws.com"),
+		"bucket":                tftypes.NewValue(tftypes.String, "mybucket"),
+		"region":                tftypes.NewValue(tftypes.String, "ap-southeast-1"),
+		"access_key_id":         tftypes.NewValue(tftypes.String, "somekey"),
+		"secret_access_key":     tftypes.NewValue(tftypes.String, "somesecret"),
+		"insterra_component_id": tftypes.NewValue(tftypes.Number, 7),
+		"last_updated":          tftypes.NewValue(tftypes.String, "2024-01-01T00:00:00Z"),
+	}
+
+	testCases := map[string]struct {
+		value    tftypes.Value
+		replaced bool
+	}{
+		"host":                  {value: tftypes.NewValue(tftypes.String, "fly.storage.tigris.dev"), replaced: false},
+		"bucket":                {value: tftypes.NewValue(tftypes.String, "anotherbucket"), replaced: false},
+		"secret_access_key":     {value: tftypes.NewValue(tftypes.String, "anothersecret"), replaced: false},
+		"insterra_component_id": {value: tftypes.NewValue(tftypes.Number, 8), replaced: true},
+	}
+
+	for attribute, testCase := range testCases {
+		t.Run(attribute, func(t *testing.T) {
+			config := map[string]tftypes.Value{
+				"host":                  prior["host"],
+				"bucket":                prior["bucket"],
+				"region":                prior["region"],
+				"access_key_id":         prior["access_key_id"],
+				"secret_access_key":     prior["secret_access_key"],
+				"insterra_component_id": prior["insterra_component_id"],
+			}
+			config[attribute] = testCase.value
+
+			resp := acceptancetest.PlanUpdate(t, nil, "instellar_storage", prior, config)
+
+			if replaced := acceptancetest.RequiresReplace(resp, attribute); replaced != testCase.replaced {
+				t.Fatalf("expected replacement %t for %s, got %v", testCase.replaced, attribute, resp.RequiresReplace)
+			}
+		})
+	}
+}
+
+func TestStorageResourcePlanKeepsUnconfiguredInsterraComponentID(t *testing.T) {
+	testCases := map[string]tftypes.Value{
+		"reported":     tftypes.NewValue(tftypes.Number, 7),
+		"not reported": tftypes.NewValue(tftypes.Number, nil),
+	}
+
+	for name, insterraComponentID := range testCases {
+		t.Run(name, func(t *testing.T) {
+			prior := map[string]tftypes.Value{
+				"id":                    tftypes.NewValue(tftypes.String, "1"),
+				"current_state":         tftypes.NewValue(tftypes.String, "healthy"),
+				"host":                  tftypes.NewValue(tftypes.String, "s3.amazonaws.com"),
+				"bucket":                tftypes.NewValue(tftypes.String, "mybucket"),
+				"region":                tftypes.NewValue(tftypes.String, "ap-southeast-1"),
+				"access_key_id":         tftypes.NewValue(tftypes.String, "somekey"),
+				"secret_access_key":     tftypes.NewValue(tftypes.String, "somesecret"),
+				"insterra_component_id": insterraComponentID,
+				"last_updated":          tftypes.NewValue(tftypes.String, "2024-01-01T00:00:00Z"),
+			}
+
+			config := map[string]tftypes.Value{
+				"host":              prior["host"],
+				"bucket":            tftypes.NewValue(tftypes.String, "anotherbucket"),
+				"region":            prior["region"],
+				"access_key_id":     prior["access_key_id"],
+				"secret_access_key": prior["secret_access_key"],
+			}
+
+			resp := acceptancetest.PlanUpdate(t, nil, "instellar_storage", prior, config)
+
+			if acceptancetest.RequiresReplace(resp, "insterra_component_id") {
+				t.Fatalf("expected the storage not to be replaced, got %v", resp.RequiresReplace)
+			}
+		})
+	}
+}
+
 func buildConfig() string {
 	return acceptance.ProviderConfig + `
 		resource "instellar_storage" "test" {
@@ -4,11 +4,11 @@ import (
 	"context"
 	"fmt"
 
-	instc "github.com/upmaru/instellar-go"
-
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
 )
 
 var (
@@ -21,12 +21,13 @@ func NewUplinkDataSource() datasource.DataSource {
 }
 
 type uplinkDataSource struct {
-	client *instc.Client
+	client *apiclient.Client
 }
 
 type uplinkDataSourceModel struct {
-	ID    types.String `tfsdk:"id"`
-	Nodes types.List   `tfsdk:"nodes"`
+	ID           types.String `tfsdk:"id"`
+	Organization types.String `tfsdk:"organization"`
+	Nodes        types.List   `tfsdk:"nodes"`
 }
 
 func (d *uplinkDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -41,6 +42,10 @@ func (d *uplinkDataSource) Schema(_ context.Context, _ datasource.SchemaRequest,
 				Description: "Uplink id",
 				Required:    true,
 			},
+			"organization": schema.StringAttribute{
+				Description: "Organization owning the uplink, defaults to the organization of the provider",
+				Optional:    true,
+			},
 			"nodes": schema.ListAttribute{
 				Description: "List of nodes",
 				Computed:    true,
@@ -55,12 +60,12 @@ func (d *uplinkDataSource) Configure(_ context.Context, req datasource.Configure
 		return
 	}
 
-	client, ok := req.ProviderData.(*instc.Client)
+	client, ok := req.ProviderData.(*apiclient.Client)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *instc.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *apiclient.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 		return
 	}
@@ -78,7 +83,9 @@ func (d *uplinkDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 		return
 	}
 
-	uplink, err := d.client.GetUplink(state.ID.ValueString())
+	ctx = apiclient.WithOrganization(ctx, state.Organization.ValueString())
+
+	uplink, err := d.client.GetUplink(ctx, state.ID.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error reading uplink",
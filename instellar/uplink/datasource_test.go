@@ -15,8 +15,7 @@ func TestAccUplinkDataSource(t *testing.T) {
 	clusterNameSegments := strings.Split(clusterUUID.String(), "-")
 	clusterNameSlug := strings.Join([]string{clusterNameSegments[0], clusterNameSegments[1]}, "-")
 
-	resource.ParallelTest(t, resource.TestCase{
-		ProtoV6ProviderFactories: acceptance.TestAccProtoV6ProviderFactories,
+	acceptance.ParallelTest(t, resource.TestCase{
 		Steps: []resource.TestStep{
 			{
 				Config: buildDataConfig(clusterNameSlug, "develop", "lite"),
@@ -4,16 +4,22 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"time"
 
 	instc "github.com/upmaru/instellar-go"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+	"github.com/upmaru/terraform-provider-instellar/internal/operation"
+	"github.com/upmaru/terraform-provider-instellar/internal/organization"
+	"github.com/upmaru/terraform-provider-instellar/internal/timestamps"
+	"github.com/upmaru/terraform-provider-instellar/internal/wait"
 )
 
 var (
@@ -22,29 +28,48 @@ var (
 	_ resource.ResourceWithImportState = &uplinkResource{}
 )
 
+// states are the states Create tells apart while waiting for an uplink, the
+// API moves it from created to active.
+var states = wait.States{Ready: []string{"active"}, Failed: wait.FailedStates}
+
+// apiAttributes maps the fields of API validation errors to the attributes
+// they come from.
+var apiAttributes = map[string]path.Path{
+	"channel_slug": path.Root("channel_slug"),
+	"kit_slug":     path.Root("kit_slug"),
+	"cluster_id":   path.Root("cluster_id"),
+}
+
+// kind describes uplinks to the steps every resource shares.
+var kind = operation.Kind{Name: "uplink", Attributes: apiAttributes, States: states}
+
 func NewUplinkResource() resource.Resource {
 	return &uplinkResource{}
 }
 
 type uplinkResource struct {
-	client *instc.Client
+	client *apiclient.Client
 }
 
 type uplinkResourceModel struct {
-	ID             types.String `tfsdk:"id"`
-	ChannelSlug    types.String `tfsdk:"channel_slug"`
-	KitSlug        types.String `tfsdk:"kit_slug"`
-	CurrentState   types.String `tfsdk:"current_state"`
-	ClusterID      types.String `tfsdk:"cluster_id"`
-	InstallationID types.String `tfsdk:"installation_id"`
-	LastUpdated    types.String `tfsdk:"last_updated"`
+	ID             types.String   `tfsdk:"id"`
+	ChannelSlug    types.String   `tfsdk:"channel_slug"`
+	KitSlug        types.String   `tfsdk:"kit_slug"`
+	CurrentState   types.String   `tfsdk:"current_state"`
+	ClusterID      types.String   `tfsdk:"cluster_id"`
+	InstallationID types.String   `tfsdk:"installation_id"`
+	Organization   types.String   `tfsdk:"organization"`
+	Timeouts       timeouts.Value `tfsdk:"timeouts"`
+	CreatedAt      types.String   `tfsdk:"created_at"`
+	UpdatedAt      types.String   `tfsdk:"updated_at"`
+	LastUpdated    types.String   `tfsdk:"last_updated"`
 }
 
 func (r *uplinkResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_uplink"
 }
 
-func (r *uplinkResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *uplinkResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Uplink provides, ingress management, deployment management and environment variable management on your cluster. It routes traffic using caddy and makes sure caddy's config is up-to-date.",
 		Attributes: map[string]schema.Attribute{
@@ -70,15 +95,21 @@ func (r *uplinkResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 			"cluster_id": schema.StringAttribute{
 				Description: "Which cluster does uplink belong to",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"installation_id": schema.StringAttribute{
 				Description: "Which installation does uplink belong to",
 				Computed:    true,
 			},
-			"last_updated": schema.StringAttribute{
-				Description: "Timestamp of terraform update",
-				Computed:    true,
-			},
+			"organization": organization.ResourceAttribute(),
+			"created_at":   timestamps.CreatedAtAttribute(),
+			"updated_at":   timestamps.UpdatedAtAttribute(),
+			"last_updated": timestamps.LastUpdatedAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{Create: true, Update: true, Delete: true}),
 		},
 	}
 }
@@ -88,13 +119,13 @@ func (r *uplinkResource) Configure(_ context.Context, req resource.ConfigureRequ
 		return
 	}
 
-	client, ok := req.ProviderData.(*instc.Client)
+	client, ok := req.ProviderData.(*apiclient.Client)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
 			fmt.Sprintf(
-				"Expected *instc.Client, got: %T. Please report this issue to the provider developers.",
+				"Expected *apiclient.Client, got: %T. Please report this issue to the provider developers.",
 				req.ProviderData,
 			),
 		)
@@ -114,18 +145,21 @@ func (r *uplinkResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Create, plan.Timeouts.Create, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	uplinkSetupParams := instc.UplinkSetupParams{
 		ChannelSlug: plan.ChannelSlug.ValueString(),
 		KitSlug:     plan.KitSlug.ValueString(),
 	}
 
-	uplink, err := r.client.CreateUplink(plan.ClusterID.ValueString(), uplinkSetupParams)
+	uplink, err := r.client.WithContext(ctx).CreateUplink(plan.ClusterID.ValueString(), uplinkSetupParams)
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error creating uplink",
-			"Could not create uplink, unexpected error: "+err.Error(),
-		)
+	if kind.Failed(&resp.Diagnostics, operation.Create, err) {
 		return
 	}
 
@@ -133,7 +167,23 @@ func (r *uplinkResource) Create(ctx context.Context, req resource.CreateRequest,
 	plan.CurrentState = types.StringValue(uplink.Data.Attributes.CurrentState)
 	plan.ClusterID = types.StringValue(strconv.Itoa(uplink.Data.Attributes.ClusterID))
 	plan.InstallationID = types.StringValue(strconv.Itoa(uplink.Data.Attributes.InstallationID))
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	var reported apiclient.Reported
+
+	read := func(ctx context.Context) (string, error) {
+		uplink, err := r.client.GetUplink(apiclient.WithoutCache(ctx), plan.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		reported = uplink.Reported
+
+		return uplink.Data.Attributes.CurrentState, nil
+	}
+
+	kind.AwaitReady(ctx, &resp.Diagnostics, plan.ID.ValueString(), read, &plan.CurrentState)
+
+	timestamps.Set(ctx, reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -151,12 +201,11 @@ func (r *uplinkResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
-	uplink, err := r.client.GetUplink(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading uplink",
-			"Could not read uplink id "+state.ID.ValueString()+": "+err.Error(),
-		)
+	ctx = apiclient.WithOrganization(ctx, state.Organization.ValueString())
+
+	uplink, err := r.client.GetUplink(ctx, state.ID.ValueString())
+
+	if kind.Refresh(ctx, resp, state.ID.ValueString(), err) {
 		return
 	}
 
@@ -166,6 +215,8 @@ func (r *uplinkResource) Read(ctx context.Context, req resource.ReadRequest, res
 	state.ClusterID = types.StringValue(strconv.Itoa(uplink.Data.Attributes.ClusterID))
 	state.InstallationID = types.StringValue(strconv.Itoa(uplink.Data.Attributes.InstallationID))
 
+	timestamps.Refresh(ctx, uplink.Reported, &state.CreatedAt, &state.UpdatedAt, &state.LastUpdated)
+
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -181,27 +232,27 @@ func (r *uplinkResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Update, plan.Timeouts.Update, plan.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	uplinkSetupParams := instc.UplinkSetupParams{
 		ChannelSlug: plan.ChannelSlug.ValueString(),
 		KitSlug:     plan.KitSlug.ValueString(),
 	}
 
-	_, err := r.client.UpdateUplink(plan.ID.ValueString(), uplinkSetupParams)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error updating uplink",
-			"Could not update uplink, unexpected error: "+err.Error(),
-		)
+	_, err := r.client.WithContext(ctx).UpdateUplink(plan.ID.ValueString(), uplinkSetupParams)
+
+	if kind.Failed(&resp.Diagnostics, operation.Update, err) {
 		return
 	}
 
-	uplink, err := r.client.GetUplink(plan.ID.ValueString())
+	uplink, err := r.client.GetUplink(ctx, plan.ID.ValueString())
 
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error reading uplink",
-			"Could not read uplink ID "+plan.ID.ValueString()+": "+err.Error(),
-		)
+	if kind.ReadFailed(&resp.Diagnostics, plan.ID.ValueString(), err) {
 		return
 	}
 
@@ -209,7 +260,8 @@ func (r *uplinkResource) Update(ctx context.Context, req resource.UpdateRequest,
 	plan.KitSlug = types.StringValue(uplink.Data.Attributes.KitSlug)
 	plan.CurrentState = types.StringValue(uplink.Data.Attributes.CurrentState)
 	plan.InstallationID = types.StringValue(strconv.Itoa(uplink.Data.Attributes.InstallationID))
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+
+	timestamps.Set(ctx, uplink.Reported, &plan.CreatedAt, &plan.UpdatedAt, &plan.LastUpdated)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -226,16 +278,33 @@ func (r *uplinkResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
-	_, err := r.client.DeleteUplink(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error deleting uplink",
-			"Could not delete uplink, unexpected error: "+err.Error(),
-		)
+	ctx, cancel := kind.Start(ctx, r.client, &resp.Diagnostics, operation.Delete, state.Timeouts.Delete, state.Organization)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	_, err := r.client.WithContext(ctx).DeleteUplink(state.ID.ValueString())
+
+	if apiclient.IsNotFound(err) {
+		return
+	}
+
+	if kind.Failed(&resp.Diagnostics, operation.Delete, err) {
+		return
+	}
+
+	kind.AwaitDeletion(ctx, &resp.Diagnostics, state.ID.ValueString(), func(ctx context.Context) (string, error) {
+		uplink, err := r.client.GetUplink(apiclient.WithoutCache(ctx), state.ID.ValueString())
+		if err != nil {
+			return "", err
+		}
+
+		return uplink.Data.Attributes.CurrentState, nil
+	})
 }
 
 func (r *uplinkResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	organization.ImportState(ctx, req, resp)
 }
@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/upmaru/terraform-provider-instellar/internal/acceptance"
+	"github.com/upmaru/terraform-provider-instellar/internal/acceptancetest"
 )
 
 func TestAccUplinkResource(t *testing.T) {
@@ -15,8 +17,7 @@ func TestAccUplinkResource(t *testing.T) {
 	clusterNameSegments := strings.Split(clusterUUID.String(), "-")
 	clusterNameSlug := strings.Join([]string{clusterNameSegments[0], clusterNameSegments[1]}, "-")
 
-	resource.ParallelTest(t, resource.TestCase{
-		ProtoV6ProviderFactories: acceptance.TestAccProtoV6ProviderFactories,
+	acceptance.ParallelTest(t, resource.TestCase{
 		Steps: []resource.TestStep{
 			{
 				Config: buildConfig(clusterNameSlug, "develop", "lite"),
@@ -24,17 +25,19 @@ func TestAccUplinkResource(t *testing.T) {
 					resource.TestCheckResourceAttr("instellar_uplink.test", "channel_slug", "develop"),
 					resource.TestCheckResourceAttr("instellar_uplink.test", "kit_slug", "lite"),
 					// Verify computed attribute fields.
-					resource.TestCheckResourceAttr("instellar_uplink.test", "current_state", "created"),
+					resource.TestCheckResourceAttr("instellar_uplink.test", "current_state", "active"),
 					// Dynamic values
 					resource.TestCheckResourceAttrSet("instellar_uplink.test", "id"),
-					resource.TestCheckResourceAttrSet("instellar_uplink.test", "last_updated"),
+					resource.TestCheckResourceAttrSet("instellar_uplink.test", "created_at"),
+					resource.TestCheckResourceAttrSet("instellar_uplink.test", "updated_at"),
+					resource.TestCheckResourceAttrPair("instellar_uplink.test", "last_updated", "instellar_uplink.test", "updated_at"),
 				),
 			},
 			{
 				ResourceName:            "instellar_uplink.test",
 				ImportState:             true,
 				ImportStateVerify:       true,
-				ImportStateVerifyIgnore: []string{"last_updated", "current_state"},
+				ImportStateVerifyIgnore: []string{"current_state"},
 			},
 			{
 				Config: buildConfig(clusterNameSlug, "master", "pro"),
@@ -42,10 +45,12 @@ func TestAccUplinkResource(t *testing.T) {
 					resource.TestCheckResourceAttr("instellar_uplink.test", "channel_slug", "master"),
 					resource.TestCheckResourceAttr("instellar_uplink.test", "kit_slug", "pro"),
 					// Verify computed attribute fields.
-					resource.TestCheckResourceAttr("instellar_uplink.test", "current_state", "created"),
+					resource.TestCheckResourceAttr("instellar_uplink.test", "current_state", "active"),
 					// Dynamic values
 					resource.TestCheckResourceAttrSet("instellar_uplink.test", "id"),
-					resource.TestCheckResourceAttrSet("instellar_uplink.test", "last_updated"),
+					resource.TestCheckResourceAttrSet("instellar_uplink.test", "created_at"),
+					resource.TestCheckResourceAttrSet("instellar_uplink.test", "updated_at"),
+					resource.TestCheckResourceAttrPair("instellar_uplink.test", "last_updated", "instellar_uplink.test", "updated_at"),
 				),
 			},
 		},
@@ -69,3 +74,41 @@ func buildConfig(clusterNameSlug string, channelSlug string, kitSlug string) str
 		}
 	`, channelSlug, kitSlug)
 }
+
+func TestUplinkResourcePlanReplacesUplink(t *testing.T) {
+	prior := map[string]tftypes.Value{
+		"id":              tftypes.NewValue(tftypes.String, "1"),
+		"channel_slug":    tftypes.NewValue(tftypes.String, "develop"),
+		"kit_slug":        tftypes.NewValue(tftypes.String, "lite"),
+		"current_state":   tftypes.NewValue(tftypes.String, "healthy"),
+		"cluster_id":      tftypes.NewValue(tftypes.String, "1"),
+		"installation_id": tftypes.NewValue(tftypes.String, "1"),
+		"last_updated":    tftypes.NewValue(tftypes.String, "2024-01-01T00:00:00Z"),
+	}
+
+	testCases := map[string]struct {
+		value    tftypes.Value
+		replaced bool
+	}{
+		"cluster_id":   {value: tftypes.NewValue(tftypes.String, "2"), replaced: true},
+		"channel_slug": {value: tftypes.NewValue(tftypes.String, "master"), replaced: false},
+		"kit_slug":     {value: tftypes.NewValue(tftypes.String, "pro"), replaced: false},
+	}
+
+	for attribute, testCase := range testCases {
+		t.Run(attribute, func(t *testing.T) {
+			config := map[string]tftypes.Value{
+				"channel_slug": prior["channel_slug"],
+				"kit_slug":     prior["kit_slug"],
+				"cluster_id":   prior["cluster_id"],
+			}
+			config[attribute] = testCase.value
+
+			resp := acceptancetest.PlanUpdate(t, nil, "instellar_uplink", prior, config)
+
+			if replaced := acceptancetest.RequiresReplace(resp, attribute); replaced != testCase.replaced {
+				t.Fatalf("expected replacement %t for %s, got %v", testCase.replaced, attribute, resp.RequiresReplace)
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package acceptance
 
 import (
+	"testing"
+
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/upmaru/terraform-provider-instellar/instellar"
 )
 
@@ -13,7 +17,33 @@ const (
 )
 
 var (
+	TestAccProtoV5ProviderFactories = map[string]func() (tfprotov5.ProviderServer, error){
+		"instellar": providerserver.NewProtocol5WithError(instellar.New("test")()),
+	}
+
 	TestAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
-		"instellar": providerserver.NewProtocol6WithError(instellar.New()),
+		"instellar": providerserver.NewProtocol6WithError(instellar.New("test")()),
 	}
 )
+
+// ParallelTest runs testCase against the provider served over protocol 5,
+// then over protocol 6. The runs share resource names, so they are not run
+// in parallel with each other.
+func ParallelTest(t *testing.T, testCase resource.TestCase) {
+	t.Helper()
+	t.Parallel()
+
+	t.Run("protocol5", func(t *testing.T) {
+		protocol5 := testCase
+		protocol5.ProtoV5ProviderFactories = TestAccProtoV5ProviderFactories
+
+		resource.Test(t, protocol5)
+	})
+
+	t.Run("protocol6", func(t *testing.T) {
+		protocol6 := testCase
+		protocol6.ProtoV6ProviderFactories = TestAccProtoV6ProviderFactories
+
+		resource.Test(t, protocol6)
+	})
+}
@@ -0,0 +1,51 @@
+package acceptancetest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+)
+
+// MemoryClient returns a client configured with opts on an in-memory
+// backend of its own.
+func MemoryClient(t *testing.T, opts apiclient.Options) *apiclient.Client {
+	t.Helper()
+
+	client, err := apiclient.New(apiclient.MemoryHost+filepath.Join(t.TempDir(), "instellar.json"), apiclient.StaticToken(""), opts)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return client
+}
+
+// ServerClient returns a client configured with opts on a mock API. The
+// mock API hands out session tokens and leaves every other request to
+// handler.
+func ServerClient(t *testing.T, opts apiclient.Options, handler http.HandlerFunc) *apiclient.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if req.URL.Path == "/provision/automation/callback" {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"data":{"token":"session-token"}}`))
+			return
+		}
+
+		handler(w, req)
+	}))
+
+	t.Cleanup(server.Close)
+
+	client, err := apiclient.New(server.URL, apiclient.StaticToken("auth-token"), opts)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return client
+}
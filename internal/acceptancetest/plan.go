@@ -0,0 +1,137 @@
+package acceptancetest
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/upmaru/terraform-provider-instellar/instellar"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+)
+
+// PlanUpdate plans the update of an object of resourceType from its prior
+// state to config, with the provider configured from providerConfig on the
+// in-memory backend, and returns the response of the provider. The proposed
+// new state is built the way Terraform builds it: computed attributes left
+// out of config keep their prior value. Errors fail the test.
+func PlanUpdate(t *testing.T, providerConfig map[string]tftypes.Value, resourceType string, prior, config map[string]tftypes.Value) *tfprotov6.PlanResourceChangeResponse {
+	t.Helper()
+
+	ctx := context.Background()
+
+	server, err := providerserver.NewProtocol6WithError(instellar.New("test")())()
+	if err != nil {
+		t.Fatalf("unexpected error creating provider server: %s", err)
+	}
+
+	schemaResp, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error getting provider schema: %s", err)
+	}
+
+	resourceSchema, ok := schemaResp.ResourceSchemas[resourceType]
+	if !ok {
+		t.Fatalf("unknown resource type %s", resourceType)
+	}
+
+	providerValues := map[string]tftypes.Value{
+		"host": tftypes.NewValue(tftypes.String, apiclient.MemoryHost+filepath.Join(t.TempDir(), "instellar.json")),
+	}
+
+	for name, value := range providerConfig {
+		providerValues[name] = value
+	}
+
+	configureResp, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{
+		TerraformVersion: "1.8.0",
+		Config:           dynamicValue(t, schemaResp.Provider, providerValues),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error configuring provider: %s", err)
+	}
+
+	checkDiagnostics(t, configureResp.Diagnostics)
+
+	proposed := map[string]tftypes.Value{}
+
+	for _, attribute := range resourceSchema.Block.Attributes {
+		if value, ok := config[attribute.Name]; ok && !value.IsNull() {
+			proposed[attribute.Name] = value
+		} else if value, ok := prior[attribute.Name]; ok && attribute.Computed {
+			proposed[attribute.Name] = value
+		}
+	}
+
+	for _, block := range resourceSchema.Block.BlockTypes {
+		if value, ok := config[block.TypeName]; ok {
+			proposed[block.TypeName] = value
+		}
+	}
+
+	planResp, err := server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         resourceType,
+		PriorState:       dynamicValue(t, resourceSchema, prior),
+		ProposedNewState: dynamicValue(t, resourceSchema, proposed),
+		Config:           dynamicValue(t, resourceSchema, config),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error planning %s: %s", resourceType, err)
+	}
+
+	checkDiagnostics(t, planResp.Diagnostics)
+
+	return planResp
+}
+
+// RequiresReplace reports whether resp replaces the object because of the
+// attribute name.
+func RequiresReplace(resp *tfprotov6.PlanResourceChangeResponse, name string) bool {
+	for _, attributePath := range resp.RequiresReplace {
+		if attributePath.Equal(tftypes.NewAttributePath().WithAttributeName(name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dynamicValue encodes an object of the type of s holding values, every
+// attribute and block missing from values is null.
+func dynamicValue(t *testing.T, s *tfprotov6.Schema, values map[string]tftypes.Value) *tfprotov6.DynamicValue {
+	t.Helper()
+
+	objectType, ok := s.ValueType().(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected schema type %T", s.ValueType())
+	}
+
+	object := map[string]tftypes.Value{}
+
+	for name, attributeType := range objectType.AttributeTypes {
+		object[name] = tftypes.NewValue(attributeType, nil)
+
+		if value, ok := values[name]; ok {
+			object[name] = value
+		}
+	}
+
+	value, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, object))
+	if err != nil {
+		t.Fatalf("unexpected error encoding value: %s", err)
+	}
+
+	return &value
+}
+
+func checkDiagnostics(t *testing.T, diagnostics []*tfprotov6.Diagnostic) {
+	t.Helper()
+
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("unexpected error: %s: %s", diagnostic.Summary, diagnostic.Detail)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package acceptancetest builds the requests the provider serves in tests and
+// checks its responses. It is imported by _test.go files only.
+package acceptancetest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+)
+
+// ReadDeleted reads the object id through r against a mock API on which
+// every object has been deleted, and returns the response of Read. The
+// state read holds id and leaves every other attribute null.
+func ReadDeleted(t *testing.T, r resource.Resource, id string) *resource.ReadResponse {
+	t.Helper()
+
+	ctx := context.Background()
+
+	client := ServerClient(t, apiclient.Options{}, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":{"detail":"Not Found"}}`))
+	})
+
+	resourceSchema := configure(t, r, client)
+
+	state := tfsdk.State{Schema: resourceSchema, Raw: objectValue(t, resourceSchema, map[string]tftypes.Value{
+		"id": tftypes.NewValue(tftypes.String, id),
+	})}
+	resp := &resource.ReadResponse{State: state}
+
+	r.Read(ctx, resource.ReadRequest{State: state}, resp)
+
+	return resp
+}
+
+// Create creates an object through r configured with client, from a plan
+// holding values. Computed attributes missing from values are unknown,
+// other attributes are null.
+func Create(t *testing.T, r resource.Resource, client *apiclient.Client, values map[string]tftypes.Value) *resource.CreateResponse {
+	t.Helper()
+
+	ctx := context.Background()
+	resourceSchema := configure(t, r, client)
+
+	planned := map[string]tftypes.Value{}
+
+	for name, attribute := range resourceSchema.Attributes {
+		if _, ok := values[name]; ok || !attribute.IsComputed() {
+			continue
+		}
+
+		planned[name] = tftypes.NewValue(attribute.GetType().TerraformType(ctx), tftypes.UnknownValue)
+	}
+
+	for name, value := range values {
+		planned[name] = value
+	}
+
+	raw := objectValue(t, resourceSchema, planned)
+	resp := &resource.CreateResponse{State: tfsdk.State{Schema: resourceSchema, Raw: raw}}
+
+	r.Create(ctx, resource.CreateRequest{Plan: tfsdk.Plan{Schema: resourceSchema, Raw: raw}}, resp)
+
+	return resp
+}
+
+// Delete deletes the object id through r configured with client. The state
+// deleted holds id and leaves every other attribute null.
+func Delete(t *testing.T, r resource.Resource, client *apiclient.Client, id string) *resource.DeleteResponse {
+	t.Helper()
+
+	resourceSchema := configure(t, r, client)
+
+	state := tfsdk.State{Schema: resourceSchema, Raw: objectValue(t, resourceSchema, map[string]tftypes.Value{
+		"id": tftypes.NewValue(tftypes.String, id),
+	})}
+	resp := &resource.DeleteResponse{State: state}
+
+	r.Delete(context.Background(), resource.DeleteRequest{State: state}, resp)
+
+	return resp
+}
+
+// configure hands client to r and returns the schema of r.
+func configure(t *testing.T, r resource.Resource, client *apiclient.Client) schema.Schema {
+	t.Helper()
+
+	ctx := context.Background()
+
+	configurable, ok := r.(resource.ResourceWithConfigure)
+	if !ok {
+		t.Fatalf("resource %T cannot be configured", r)
+	}
+
+	configureResp := &resource.ConfigureResponse{}
+	configurable.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, configureResp)
+
+	if configureResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error configuring resource: %v", configureResp.Diagnostics)
+	}
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	return schemaResp.Schema
+}
+
+// objectValue returns an object of the type of resourceSchema holding
+// values, every attribute and block missing from values is null.
+func objectValue(t *testing.T, resourceSchema schema.Schema, values map[string]tftypes.Value) tftypes.Value {
+	t.Helper()
+
+	objectType, ok := resourceSchema.Type().TerraformType(context.Background()).(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected schema type %T", resourceSchema.Type().TerraformType(context.Background()))
+	}
+
+	object := map[string]tftypes.Value{}
+
+	for name, attributeType := range objectType.AttributeTypes {
+		object[name] = tftypes.NewValue(attributeType, nil)
+
+		if value, ok := values[name]; ok {
+			object[name] = value
+		}
+	}
+
+	return tftypes.NewValue(objectType, object)
+}
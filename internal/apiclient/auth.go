@@ -0,0 +1,175 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	// instellar client = instc.
+	instc "github.com/upmaru/instellar-go"
+)
+
+const (
+	authenticatePath = "/provision/automation/callback"
+
+	// tokenExpiryLeeway renews a credential slightly before it expires so a
+	// request is never sent with a token that expires in flight.
+	tokenExpiryLeeway = 30 * time.Second
+)
+
+// Token is a credential exchanged with the Instellar API for a session. A
+// zero ExpiresAt means the credential does not expire. Bearer tokens are
+// sent to the API as is instead of being exchanged.
+type Token struct {
+	Value     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Bearer    bool      `json:"-"`
+}
+
+// TokenSource provides the credential used to open an API session.
+type TokenSource interface {
+	Token(ctx context.Context) (Token, error)
+}
+
+type staticToken string
+
+// StaticToken returns a source for a literal auth token.
+func StaticToken(value string) TokenSource {
+	return staticToken(value)
+}
+
+func (t staticToken) Token(_ context.Context) (Token, error) {
+	return Token{Value: string(t)}, nil
+}
+
+type commandToken struct {
+	argv []string
+}
+
+// CommandToken returns a source that runs an external credential helper. The
+// helper must print a JSON document such as
+// {"token": "...", "expires_at": "2024-01-01T00:00:00Z"} on stdout.
+func CommandToken(argv []string) TokenSource {
+	return &commandToken{argv: argv}
+}
+
+func (c *commandToken) Token(ctx context.Context) (Token, error) {
+	if len(c.argv) == 0 {
+		return Token{}, errors.New("auth token command is empty")
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, c.argv[0], c.argv[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return Token{}, fmt.Errorf("auth token command %q failed: %w: %s", c.argv[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	var token Token
+
+	if err := json.Unmarshal(stdout.Bytes(), &token); err != nil {
+		return Token{}, fmt.Errorf("auth token command %q did not print a valid JSON document: %w", c.argv[0], err)
+	}
+
+	if token.Value == "" {
+		return Token{}, fmt.Errorf("auth token command %q returned an empty token", c.argv[0])
+	}
+
+	return token, nil
+}
+
+// session exchanges the credential from a TokenSource for the bearer token
+// sent with API calls. The bearer token is cached for the lifetime of the
+// provider and renewed once the credential expires.
+type session struct {
+	mu        sync.Mutex
+	host      string
+	source    TokenSource
+	transport http.RoundTripper
+	bearer    string
+	expiresAt time.Time
+}
+
+func (s *session) Bearer(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bearer != "" && (s.expiresAt.IsZero() || time.Now().Add(tokenExpiryLeeway).Before(s.expiresAt)) {
+		return s.bearer, nil
+	}
+
+	token, err := s.source.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if token.Bearer {
+		s.bearer = token.Value
+		s.expiresAt = token.ExpiresAt
+
+		return s.bearer, nil
+	}
+
+	bearer, err := s.exchange(ctx, token.Value)
+	if err != nil {
+		return "", err
+	}
+
+	s.bearer = bearer
+	s.expiresAt = token.ExpiresAt
+
+	return s.bearer, nil
+}
+
+// exchange trades a credential for a session bearer token.
+func (s *session) exchange(ctx context.Context, credential string) (string, error) {
+	body, err := json.Marshal(instc.CredentialStruct{Token: credential})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.host+authenticatePath, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	auth := instc.AuthResponse{}
+
+	if err := do(&http.Client{Transport: s.transport}, req, &auth); err != nil {
+		return "", err
+	}
+
+	return auth.Data.Token, nil
+}
+
+// authTransport sends the current session bearer token with every request.
+type authTransport struct {
+	base    http.RoundTripper
+	session *session
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, authenticatePath) || strings.HasSuffix(req.URL.Path, "/"+healthPath) {
+		return t.base.RoundTrip(req)
+	}
+
+	bearer, err := t.session.Bearer(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+bearer)
+
+	return t.base.RoundTrip(req)
+}
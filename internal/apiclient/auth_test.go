@@ -0,0 +1,133 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// credentialHelper writes a shell script printing the given token document and
+// counting its invocations in a file next to it.
+func credentialHelper(t *testing.T, document string) ([]string, func() int) {
+	t.Helper()
+
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "calls")
+	script := filepath.Join(dir, "helper.sh")
+
+	contents := fmt.Sprintf("#!/bin/sh\necho run >> %q\ncat <<'EOF'\n%s\nEOF\n", counter, document)
+
+	if err := os.WriteFile(script, []byte(contents), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := func() int {
+		data, _ := os.ReadFile(counter)
+		return strings.Count(string(data), "run")
+	}
+
+	return []string{"/bin/sh", script}, calls
+}
+
+func sessionServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var exchanges int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == authenticatePath {
+			var credential struct {
+				Token string `json:"auth_token"`
+			}
+
+			_ = json.NewDecoder(r.Body).Decode(&credential)
+			n := atomic.AddInt32(&exchanges, 1)
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, `{"data":{"token":"%s-session-%d"}}`, credential.Token, n)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{"data":{"attributes":{"slug":%q}}}`, r.Header.Get("Authorization"))
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, &exchanges
+}
+
+func TestCommandTokenIsCachedUntilExpiry(t *testing.T) {
+	server, exchanges := sessionServer(t)
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	argv, calls := credentialHelper(t, fmt.Sprintf(`{"token": "helper", "expires_at": %q}`, expiresAt))
+
+	client, err := New(server.URL, CommandToken(argv), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		cluster, err := client.WithContext(context.Background()).GetCluster("1")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if cluster.Data.Attributes.Slug != "Bearer helper-session-1" {
+			t.Errorf("expected cached session token, got %q", cluster.Data.Attributes.Slug)
+		}
+	}
+
+	if calls() != 1 || *exchanges != 1 {
+		t.Errorf("expected command to run once, got %d runs and %d exchanges", calls(), *exchanges)
+	}
+}
+
+func TestCommandTokenIsRenewedWhenExpired(t *testing.T) {
+	server, exchanges := sessionServer(t)
+	expiresAt := time.Now().Add(time.Second).UTC().Format(time.RFC3339)
+	argv, calls := credentialHelper(t, fmt.Sprintf(`{"token": "helper", "expires_at": %q}`, expiresAt))
+
+	client, err := New(server.URL, CommandToken(argv), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := client.WithContext(context.Background()).GetCluster("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cluster, err := client.WithContext(context.Background()).GetCluster("1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if cluster.Data.Attributes.Slug != "Bearer helper-session-2" {
+		t.Errorf("expected renewed session token, got %q", cluster.Data.Attributes.Slug)
+	}
+
+	if calls() != 2 || *exchanges != 2 {
+		t.Errorf("expected command to run twice, got %d runs and %d exchanges", calls(), *exchanges)
+	}
+}
+
+func TestCommandTokenReportsInvalidOutput(t *testing.T) {
+	argv, _ := credentialHelper(t, `not json`)
+
+	if _, err := CommandToken(argv).Token(context.Background()); err == nil || !strings.Contains(err.Error(), "valid JSON") {
+		t.Errorf("expected invalid JSON error, got %v", err)
+	}
+
+	argv, _ = credentialHelper(t, `{"token": ""}`)
+
+	if _, err := CommandToken(argv).Token(context.Background()); err == nil || !strings.Contains(err.Error(), "empty token") {
+		t.Errorf("expected empty token error, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// DefaultCacheTTL is how long a GET response is reused. It is long enough to
+// cover the refresh of a Terraform operation and short enough for resources
+// polling the API to observe changes.
+const DefaultCacheTTL = 5 * time.Second
+
+// cacheTransport reuses successful GET responses for the same URL, so
+// resources and data sources reading the same object during one operation
+// hit the API once. Concurrent reads of a URL share a single request. Any
+// other request drops every cached response, whether it succeeds or not.
+type cacheTransport struct {
+	base http.RoundTripper
+	ttl  time.Duration
+
+	mu sync.Mutex
+	// generation changes on every write, a read started before a write does
+	// not store its response.
+	generation uint64
+	entries    map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	// ready is closed once the response is stored or the request failed.
+	ready     chan struct{}
+	expiresAt time.Time
+	cached    bool
+
+	status int
+	header http.Header
+	body   []byte
+}
+
+type freshKey struct{}
+
+// WithoutCache returns a context whose GET requests always reach the API,
+// for callers polling an object until it changes. Their responses still
+// replace the cached ones.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshKey{}, true)
+}
+
+func fresh(ctx context.Context) bool {
+	skip, _ := ctx.Value(freshKey{}).(bool)
+
+	return skip
+}
+
+func newCacheTransport(base http.RoundTripper, ttl time.Duration) http.RoundTripper {
+	if ttl <= 0 {
+		return base
+	}
+
+	return &cacheTransport{base: base, ttl: ttl, entries: map[string]*cacheEntry{}}
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		t.invalidate()
+		defer t.invalidate()
+
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.Header.Get(OrganizationHeader) + " " + req.URL.String()
+
+	for {
+		t.mu.Lock()
+		entry, found := t.entries[key]
+
+		if found && entry.cached && (fresh(req.Context()) || time.Now().After(entry.expiresAt)) {
+			delete(t.entries, key)
+			found = false
+		}
+
+		if !found {
+			break
+		}
+
+		t.mu.Unlock()
+
+		select {
+		case <-entry.ready:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		if entry.cached {
+			tflog.SubsystemDebug(tflog.NewSubsystem(req.Context(), LoggingSubsystem), LoggingSubsystem, "Reusing cached HTTP response", map[string]any{
+				"http_method": req.Method,
+				"http_path":   req.URL.Path,
+				"http_status": entry.status,
+			})
+
+			return entry.response(req), nil
+		}
+	}
+
+	entry := &cacheEntry{ready: make(chan struct{})}
+	generation := t.generation
+	t.entries[key] = entry
+	t.mu.Unlock()
+
+	resp, err := t.base.RoundTrip(req)
+
+	var body []byte
+
+	if err == nil {
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	defer close(entry.ready)
+
+	if err != nil || resp.StatusCode != http.StatusOK || generation != t.generation {
+		if t.entries[key] == entry {
+			delete(t.entries, key)
+		}
+
+		return resp, err
+	}
+
+	entry.cached = true
+	entry.expiresAt = time.Now().Add(t.ttl)
+	entry.status = resp.StatusCode
+	entry.header = resp.Header.Clone()
+	entry.body = body
+
+	return resp, nil
+}
+
+func (t *cacheTransport) invalidate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.generation++
+
+	for key, entry := range t.entries {
+		if entry.cached {
+			delete(t.entries, key)
+		}
+	}
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,159 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	instc "github.com/upmaru/instellar-go"
+)
+
+// uplinkServer serves a single uplink whose channel is changed by updates and
+// counts the reads reaching the API.
+func uplinkServer(t *testing.T, ttl time.Duration) (*Client, *int32) {
+	t.Helper()
+
+	var (
+		mu    sync.Mutex
+		reads int32
+	)
+
+	channel := "develop"
+
+	client := newTestClient(t, Options{CacheTTL: ttl}, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			atomic.AddInt32(&reads, 1)
+			time.Sleep(10 * time.Millisecond)
+		case http.MethodPatch:
+			var params struct {
+				Uplink instc.UplinkSetupParams `json:"uplink"`
+			}
+
+			_ = json.NewDecoder(r.Body).Decode(&params)
+			channel = params.Uplink.ChannelSlug
+		}
+
+		_, _ = fmt.Fprintf(w, `{"data":{"attributes":{"id":1,"channel_slug":%q}}}`, channel)
+	})
+
+	return client, &reads
+}
+
+func TestCacheReusesReads(t *testing.T) {
+	client, reads := uplinkServer(t, time.Minute)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := client.WithContext(context.Background()).GetUplink("1"); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if _, err := client.WithContext(context.Background()).GetUplink("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if *reads != 1 {
+		t.Errorf("expected a single read to reach the API, got %d", *reads)
+	}
+}
+
+func TestCacheIsInvalidatedByWrites(t *testing.T) {
+	client, reads := uplinkServer(t, time.Minute)
+	api := client.WithContext(context.Background())
+
+	if _, err := api.GetUplink("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := api.UpdateUplink("1", instc.UplinkSetupParams{ChannelSlug: "master"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	uplink, err := api.GetUplink("1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if uplink.Data.Attributes.ChannelSlug != "master" {
+		t.Errorf("expected updated channel after write, got %q", uplink.Data.Attributes.ChannelSlug)
+	}
+
+	if *reads != 2 {
+		t.Errorf("expected the write to force a new read, got %d reads", *reads)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	client, reads := uplinkServer(t, 20*time.Millisecond)
+	api := client.WithContext(context.Background())
+
+	if _, err := api.GetUplink("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, err := api.GetUplink("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if *reads != 2 {
+		t.Errorf("expected expired response to be read again, got %d reads", *reads)
+	}
+}
+
+func TestCacheIsBypassedWithoutCache(t *testing.T) {
+	client, reads := uplinkServer(t, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.WithContext(WithoutCache(context.Background())).GetUplink("1"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if _, err := client.WithContext(context.Background()).GetUplink("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if *reads != 2 {
+		t.Errorf("expected polling reads to reach the API and be reused by the next read, got %d reads", *reads)
+	}
+}
+
+func TestCacheSkipsFailedReads(t *testing.T) {
+	var reads int32
+
+	client := newTestClient(t, Options{CacheTTL: time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reads, 1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.WithContext(context.Background()).GetUplink("1"); err == nil {
+			t.Fatalf("expected not found error")
+		}
+	}
+
+	if reads != 2 {
+		t.Errorf("expected failed reads not to be cached, got %d reads", reads)
+	}
+}
@@ -0,0 +1,174 @@
+package apiclient
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	// instellar client = instc.
+	instc "github.com/upmaru/instellar-go"
+)
+
+const (
+	DefaultMaxRetries     = 4
+	DefaultRetryMaxWait   = 30 * time.Second
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+// Options configure the HTTP transport shared by every Instellar API call.
+type Options struct {
+	// MaxRetries is the number of times a failed request is retried.
+	MaxRetries int
+	// RetryMaxWait caps the delay between two attempts.
+	RetryMaxWait time.Duration
+	// RequestTimeout bounds a single attempt of a request.
+	RequestTimeout time.Duration
+	// MaxConcurrentRequests caps the number of requests in flight, zero
+	// means no limit.
+	MaxConcurrentRequests int
+	// RequestsPerSecond caps the rate of requests, zero means no limit.
+	RequestsPerSecond int
+	// CacheTTL is how long successful GET responses are reused, zero
+	// disables the cache.
+	CacheTTL time.Duration
+	// TLSConfig overrides the TLS settings used to reach the API host.
+	TLSConfig *tls.Config
+	// ProxyURL routes every request through the given proxy instead of the
+	// one configured in the environment.
+	ProxyURL *url.URL
+	// Headers are added to every request.
+	Headers map[string]string
+	// UserAgent is sent with every request.
+	UserAgent string
+	// Organization is sent with every request that does not override it with
+	// WithOrganization.
+	Organization string
+	// ReadOnly refuses every request that could modify a resource.
+	ReadOnly bool
+	// Defaults are handed to resources as is.
+	Defaults Defaults
+}
+
+// Defaults are the values of the provider defaults block, used by resources
+// for attributes left out of their configuration. Empty values have no
+// default.
+type Defaults struct {
+	ProviderName string
+	Region       string
+	Channels     []string
+}
+
+// Client is handed to resources and data sources as provider data. It holds
+// the configured instellar client and binds its requests to the context of
+// the Terraform operation that issues them.
+type Client struct {
+	api      *instc.Client
+	session  *session
+	readOnly bool
+	defaults Defaults
+}
+
+// New returns a client whose HTTP requests go through the transport
+// described by opts. A session is opened with the credential from tokens on
+// the first request, see Authenticate to validate the credential right away.
+func New(host string, tokens TokenSource, opts Options) (*Client, error) {
+	api, err := instc.NewClient(&host, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var transport http.RoundTripper = baseTransport(opts)
+
+	if IsMemoryHost(host) {
+		transport, err = newMemoryTransport(host)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transport = &loggingTransport{base: transport}
+	transport = newHeaderTransport(transport, requestHeaders(opts))
+	transport = newTimeoutTransport(transport, opts.RequestTimeout)
+	transport = newLimitTransport(transport, opts.MaxConcurrentRequests, opts.RequestsPerSecond)
+	transport = newRetryTransport(transport, opts.MaxRetries, opts.RetryMaxWait)
+
+	// The token endpoint is another service, it gets none of the headers
+	// meant for the API.
+	if source, ok := tokens.(*clientCredentials); ok {
+		source.transport = newRetryTransport(newTimeoutTransport(baseTransport(opts), opts.RequestTimeout), opts.MaxRetries, opts.RetryMaxWait)
+	}
+
+	session := &session{
+		host:      api.HostURL,
+		source:    tokens,
+		transport: transport,
+	}
+
+	transport = &authTransport{base: transport, session: session}
+	transport = newCacheTransport(transport, opts.CacheTTL)
+
+	if opts.ReadOnly {
+		transport = &readOnlyTransport{base: transport}
+	}
+
+	api.HTTPClient = &http.Client{Transport: transport}
+
+	return &Client{api: api, session: session, readOnly: opts.ReadOnly, defaults: opts.Defaults}, nil
+}
+
+// WithContext returns an instellar client whose requests are tied to ctx.
+// Cancelling ctx aborts any request in flight.
+func (c *Client) WithContext(ctx context.Context) *instc.Client {
+	api := *c.api
+	api.HTTPClient = &http.Client{
+		Transport: &contextTransport{
+			ctx:  ctx,
+			base: c.api.HTTPClient.Transport,
+		},
+	}
+
+	return &api
+}
+
+// Defaults returns the values of the provider defaults block.
+func (c *Client) Defaults() Defaults {
+	return c.defaults
+}
+
+func requestHeaders(opts Options) map[string]string {
+	headers := make(map[string]string, len(opts.Headers)+2)
+
+	for name, value := range opts.Headers {
+		headers[name] = value
+	}
+
+	if opts.UserAgent != "" {
+		headers["User-Agent"] = opts.UserAgent
+	}
+
+	if opts.Organization != "" {
+		headers[OrganizationHeader] = opts.Organization
+	}
+
+	return headers
+}
+
+func baseTransport(opts Options) *http.Transport {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+
+	if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = defaultTransport.Clone()
+	}
+
+	if opts.TLSConfig != nil {
+		transport.TLSClientConfig = opts.TLSConfig
+	}
+
+	if opts.ProxyURL != nil {
+		transport.Proxy = http.ProxyURL(opts.ProxyURL)
+	}
+
+	return transport
+}
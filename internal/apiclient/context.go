@@ -0,0 +1,88 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrCanceled is returned when the Terraform operation that issued a request
+// was cancelled, for example by interrupting terraform apply.
+var ErrCanceled = errors.New("request cancelled before the Instellar API responded")
+
+// contextTransport binds requests created without a context by the instellar
+// client to the context of the Terraform operation, and to the organization
+// set on that context with WithOrganization.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCanceled, err)
+	}
+
+	if organization, ok := organizationFrom(t.ctx); ok {
+		req = req.Clone(t.ctx)
+		req.Header.Set(OrganizationHeader, organization)
+	} else {
+		req = req.WithContext(t.ctx)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+
+	if err != nil && t.ctx.Err() != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCanceled, t.ctx.Err())
+	}
+
+	return resp, err
+}
+
+// timeoutTransport bounds every attempt of a request. The deadline covers
+// reading the response body, which is released once the body is closed.
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func newTimeoutTransport(base http.RoundTripper, timeout time.Duration) http.RoundTripper {
+	if timeout <= 0 {
+		return base
+	}
+
+	return &timeoutTransport{base: base, timeout: timeout}
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && req.Context().Err() == nil {
+			err = fmt.Errorf("instellar API did not respond within request_timeout (%s): %w", t.timeout, err)
+		}
+
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
@@ -0,0 +1,68 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const authResponse = `{"data":{"token":"session-token"}}`
+
+// newTestClient returns a client for a test server that opens sessions and
+// answers every other request with handler.
+func newTestClient(t *testing.T, opts Options, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/provision/automation/callback" {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(authResponse))
+			return
+		}
+
+		handler(w, r)
+	}))
+
+	t.Cleanup(server.Close)
+
+	client, err := New(server.URL, StaticToken("auth-token"), opts)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return client
+}
+
+func TestWithContextAbortsRequestsWhenCancelled(t *testing.T) {
+	client := newTestClient(t, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := client.WithContext(ctx).GetCluster("1")
+
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected cancelled error, got %v", err)
+	}
+}
+
+func TestRequestTimeoutBoundsEachRequest(t *testing.T) {
+	client := newTestClient(t, Options{RequestTimeout: 50 * time.Millisecond}, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	_, err := client.WithContext(context.Background()).GetCluster("1")
+
+	if err == nil || !strings.Contains(err.Error(), "request_timeout") {
+		t.Fatalf("expected request timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package apiclient
+
+import (
+	"net/http"
+)
+
+// headerTransport adds extra headers to every request. Headers already set by
+// the instellar client, such as Authorization, are left untouched.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func newHeaderTransport(base http.RoundTripper, headers map[string]string) http.RoundTripper {
+	if len(headers) == 0 {
+		return base
+	}
+
+	return &headerTransport{base: base, headers: headers}
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	for name, value := range t.headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
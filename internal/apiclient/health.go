@@ -0,0 +1,29 @@
+package apiclient
+
+import (
+	"context"
+)
+
+const healthPath = "provision/automation/health"
+
+// Health describes the status and version of the Instellar API.
+type Health struct {
+	Data struct {
+		Attributes struct {
+			Status  string `json:"status"`
+			Version string `json:"version"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// Health returns the status and version of the API. It does not need a valid
+// credential, so it tells an unhealthy API apart from a rejected credential.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	health := Health{}
+
+	if err := c.get(ctx, healthPath, &health); err != nil {
+		return nil, err
+	}
+
+	return &health, nil
+}
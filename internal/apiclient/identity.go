@@ -0,0 +1,40 @@
+package apiclient
+
+import (
+	"context"
+)
+
+const identityPath = "provision/automation/identity"
+
+// Identity describes the account and organization the configured
+// credentials belong to.
+type Identity struct {
+	Data struct {
+		Attributes struct {
+			Account      string `json:"account"`
+			Organization string `json:"organization"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// Authenticate opens a session with the configured credentials through the
+// token exchange every request relies on, so a rejected credential is
+// reported right away instead of on the first resource operation.
+func (c *Client) Authenticate(ctx context.Context) error {
+	_, err := c.session.Bearer(ctx)
+
+	return err
+}
+
+// WhoAmI returns the identity the configured credentials belong to. Not
+// every Instellar API serves it, callers only use it to describe the
+// session and treat any error as a missing identity.
+func (c *Client) WhoAmI(ctx context.Context) (*Identity, error) {
+	identity := Identity{}
+
+	if err := c.get(ctx, identityPath, &identity); err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
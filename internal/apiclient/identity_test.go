@@ -0,0 +1,99 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWhoAmIReturnsIdentity(t *testing.T) {
+	client := newTestClient(t, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+identityPath || r.Header.Get("Authorization") != "Bearer session-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"attributes":{"account":"zacksiri","organization":"upmaru"}}}`))
+	})
+
+	identity, err := client.WhoAmI(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if identity.Data.Attributes.Account != "zacksiri" || identity.Data.Attributes.Organization != "upmaru" {
+		t.Errorf("unexpected identity: %+v", identity.Data.Attributes)
+	}
+}
+
+func TestWhoAmIReportsRejectedCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors":{"detail":"Unauthorized"}}`))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, StaticToken("revoked"), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = client.WhoAmI(context.Background())
+
+	var apiErr *APIError
+
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized API error, got %v", err)
+	}
+}
+
+func TestAuthenticateExchangesCredential(t *testing.T) {
+	var paths []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		if r.URL.Path != authenticatePath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(authResponse))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, StaticToken("auth-token"), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := client.Authenticate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(paths) != 1 || paths[0] != authenticatePath {
+		t.Errorf("expected only the token exchange to be requested, got %v", paths)
+	}
+}
+
+func TestAuthenticateReportsRejectedCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors":{"detail":"Unauthorized"}}`))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, StaticToken("revoked"), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var apiErr *APIError
+
+	if err := client.Authenticate(context.Background()); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized API error, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package apiclient
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// limitTransport caps the number of requests in flight and spaces requests
+// so that no more than the configured number is sent per second. It sits
+// below the retry transport, so every attempt counts against the limits, and
+// is shared by every resource and data source of a provider instance.
+type limitTransport struct {
+	base http.RoundTripper
+	// slots holds one element per request in flight, nil when the number of
+	// requests in flight is not limited.
+	slots chan struct{}
+	// interval is the minimum delay between two requests, zero when the
+	// request rate is not limited.
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newLimitTransport(base http.RoundTripper, maxConcurrent int, perSecond int) http.RoundTripper {
+	if maxConcurrent <= 0 && perSecond <= 0 {
+		return base
+	}
+
+	transport := &limitTransport{base: base}
+
+	if maxConcurrent > 0 {
+		transport.slots = make(chan struct{}, maxConcurrent)
+	}
+
+	if perSecond > 0 {
+		transport.interval = time.Second / time.Duration(perSecond)
+	}
+
+	return transport
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release := t.release
+
+	if wait := t.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+// reserve books the next send time and returns how long the caller has to
+// wait for it.
+func (t *limitTransport) reserve() time.Duration {
+	if t.interval == 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+
+	if t.next.Before(now) {
+		t.next = now
+	}
+
+	wait := t.next.Sub(now)
+	t.next = t.next.Add(t.interval)
+
+	return wait
+}
+
+func (t *limitTransport) release() {
+	if t.slots != nil {
+		<-t.slots
+	}
+}
+
+// releaseOnClose frees the concurrency slot of a request once its response
+// body is closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+
+	return err
+}
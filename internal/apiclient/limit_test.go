@@ -0,0 +1,85 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimitTransportCapsConcurrentRequests(t *testing.T) {
+	var inFlight, peak int32
+
+	client := newTestClient(t, Options{MaxConcurrentRequests: 2}, func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		for {
+			previous := atomic.LoadInt32(&peak)
+			if current <= previous || atomic.CompareAndSwapInt32(&peak, previous, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"data":{"attributes":{"slug":"pizza"}}}`))
+	})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := client.WithContext(context.Background()).GetCluster("1"); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", peak)
+	}
+}
+
+func TestLimitTransportSpacesRequests(t *testing.T) {
+	client := newTestClient(t, Options{RequestsPerSecond: 20}, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"attributes":{"slug":"pizza"}}}`))
+	})
+
+	start := time.Now()
+
+	// The session exchange counts as the first of the 5 requests.
+	for i := 0; i < 4; i++ {
+		if _, err := client.WithContext(context.Background()).GetCluster("1"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected 5 requests to take at least 200ms, took %s", elapsed)
+	}
+}
+
+func TestLimitTransportGivesUpWhenCancelled(t *testing.T) {
+	client := newTestClient(t, Options{RequestsPerSecond: 1}, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"attributes":{"slug":"pizza"}}}`))
+	})
+
+	if _, err := client.WithContext(context.Background()).GetCluster("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.WithContext(ctx).GetCluster("1"); err == nil {
+		t.Errorf("expected cancelled request to fail while waiting for the limiter")
+	}
+}
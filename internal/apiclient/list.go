@@ -0,0 +1,118 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	// instellar client = instc.
+	instc "github.com/upmaru/instellar-go"
+)
+
+// ListClusters returns every cluster of the organization.
+func (c *Client) ListClusters(ctx context.Context) ([]Cluster, error) {
+	return list[Cluster](ctx, c, "provision/clusters")
+}
+
+// ListNodes returns the nodes of a cluster.
+func (c *Client) ListNodes(ctx context.Context, clusterID string) ([]instc.Node, error) {
+	return list[instc.Node](ctx, c, fmt.Sprintf("provision/clusters/%s/nodes", clusterID))
+}
+
+// ListUplinks returns the uplinks of a cluster.
+func (c *Client) ListUplinks(ctx context.Context, clusterID string) ([]instc.Uplink, error) {
+	return list[instc.Uplink](ctx, c, fmt.Sprintf("provision/clusters/%s/uplinks", clusterID))
+}
+
+// ListBalancers returns the balancers of a cluster.
+func (c *Client) ListBalancers(ctx context.Context, clusterID string) ([]instc.Balancer, error) {
+	return list[instc.Balancer](ctx, c, fmt.Sprintf("provision/clusters/%s/balancers", clusterID))
+}
+
+// ListComponents returns every component of the organization.
+func (c *Client) ListComponents(ctx context.Context) ([]Component, error) {
+	return list[Component](ctx, c, "provision/components")
+}
+
+// ListStorages returns every storage of the organization.
+func (c *Client) ListStorages(ctx context.Context) ([]Storage, error) {
+	return list[Storage](ctx, c, "provision/storages")
+}
+
+// list fetches a collection, whose data is an array of the data documents
+// returned for a single object, and decodes every element into a T. A
+// collection split into pages links to the next one, every page is fetched.
+func list[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	var objects []T
+
+	page := fmt.Sprintf("%s/%s", c.api.HostURL, path)
+	seen := map[string]bool{}
+
+	for page != "" {
+		seen[page] = true
+
+		collection := struct {
+			Data  []json.RawMessage `json:"data"`
+			Links struct {
+				Next string `json:"next"`
+			} `json:"links"`
+		}{}
+
+		if err := c.getURL(ctx, page, &collection); err != nil {
+			return nil, err
+		}
+
+		for _, data := range collection.Data {
+			document, err := json.Marshal(map[string]json.RawMessage{"data": data})
+			if err != nil {
+				return nil, err
+			}
+
+			var object T
+
+			if err := json.Unmarshal(document, &object); err != nil {
+				return nil, err
+			}
+
+			objects = append(objects, object)
+		}
+
+		next, err := nextPage(page, collection.Links.Next)
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", path, err)
+		}
+
+		if seen[next] {
+			return nil, fmt.Errorf("listing %s: the page %s links back to %s", path, page, next)
+		}
+
+		page = next
+	}
+
+	return objects, nil
+}
+
+// nextPage resolves the link to the next page of the collection at page. It
+// refuses links leaving the host, the credential is sent along with them.
+func nextPage(page string, link string) (string, error) {
+	if link == "" {
+		return "", nil
+	}
+
+	base, err := url.Parse(page)
+	if err != nil {
+		return "", err
+	}
+
+	next, err := base.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("unexpected link to the next page %q: %w", link, err)
+	}
+
+	if next.Scheme != base.Scheme || next.Host != base.Host {
+		return "", fmt.Errorf("the link to the next page %s leaves the host %s", next, base.Host)
+	}
+
+	return next.String(), nil
+}
@@ -0,0 +1,121 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	instc "github.com/upmaru/instellar-go"
+)
+
+func TestListDecodesCollections(t *testing.T) {
+	var paths []string
+
+	client := newTestClient(t, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		_, _ = w.Write([]byte(`{"data":[{"attributes":{"id":2,"slug":"pizza-node-01","cluster_id":1}},{"attributes":{"id":3,"slug":"pizza-node-02","cluster_id":1}}]}`))
+	})
+
+	nodes, err := client.ListNodes(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(nodes) != 2 || nodes[0].Data.Attributes.Slug != "pizza-node-01" || nodes[1].Data.Attributes.ID != 3 {
+		t.Errorf("unexpected nodes %+v", nodes)
+	}
+
+	if len(paths) != 1 || paths[0] != "/provision/clusters/1/nodes" {
+		t.Errorf("expected nodes of cluster 1 to be requested, got %v", paths)
+	}
+}
+
+func TestListFollowsLinksToNextPages(t *testing.T) {
+	var queries []string
+
+	client := newTestClient(t, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+
+		switch r.URL.Query().Get("page") {
+		case "":
+			_, _ = w.Write([]byte(`{"data":[{"attributes":{"id":1,"slug":"pizza-cluster"}}],"links":{"next":"/provision/clusters?page=2"}}`))
+		case "2":
+			_, _ = w.Write([]byte(`{"data":[{"attributes":{"id":2,"slug":"pasta-cluster"}}],"links":{"next":"http://` + r.Host + `/provision/clusters?page=3"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":[{"attributes":{"id":3,"slug":"salad-cluster"}}],"links":{"self":"http://` + r.Host + `/provision/clusters?page=3"}}`))
+		}
+	})
+
+	clusters, err := client.ListClusters(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(clusters) != 3 || clusters[1].Data.Attributes.Slug != "pasta-cluster" || clusters[2].Data.Attributes.ID != 3 {
+		t.Errorf("expected clusters of every page, got %+v", clusters)
+	}
+
+	if len(queries) != 3 || queries[2] != "page=3" {
+		t.Errorf("expected three pages to be requested, got %v", queries)
+	}
+}
+
+func TestListRejectsUnfollowableLinks(t *testing.T) {
+	tests := map[string]string{
+		"other host": "https://example.com/provision/clusters?page=2",
+		"loop":       "/provision/clusters",
+	}
+
+	for name, next := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := newTestClient(t, Options{}, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data":[],"links":{"next":"` + next + `"}}`))
+			})
+
+			if _, err := client.ListClusters(context.Background()); err == nil {
+				t.Errorf("expected listing to fail when the next page is %s", next)
+			}
+		})
+	}
+}
+
+func TestMemoryBackendListsObjects(t *testing.T) {
+	api, host := newMemoryClient(t)
+	clusterID := createMemoryCluster(t, api)
+
+	if _, err := api.CreateNode(clusterID, "pizza-node-01", instc.NodeParams{PublicIP: "10.0.0.1"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client, err := New(host, StaticToken(""), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	clusters, err := client.ListClusters(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(clusters) != 1 || clusters[0].Data.Attributes.Slug != "pizza-cluster" {
+		t.Errorf("unexpected clusters %+v", clusters)
+	}
+
+	nodes, err := client.ListNodes(context.Background(), clusterID)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(nodes) != 1 || nodes[0].Data.Attributes.PublicIP != "10.0.0.1" {
+		t.Errorf("unexpected nodes %+v", nodes)
+	}
+
+	if _, err := api.DeleteCluster(clusterID); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if clusters, err := client.ListClusters(context.Background()); err != nil || len(clusters) != 0 {
+		t.Errorf("expected deleting cluster to be left out, got %+v, %v", clusters, err)
+	}
+}
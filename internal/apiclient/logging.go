@@ -0,0 +1,200 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// LoggingSubsystem is the tflog subsystem of the HTTP wire logs. Its level
+	// can be set separately with TF_LOG_PROVIDER_INSTELLAR_HTTP.
+	LoggingSubsystem = "instellar_http"
+
+	redacted = "[REDACTED]"
+)
+
+// sensitiveKeys are matched against header names, JSON keys and form fields.
+// Any name containing one of them has its value redacted, which covers
+// password, password_token, credential_password, secret_access_key,
+// credential_secret_access_key, auth_token and Authorization.
+var sensitiveKeys = []string{"password", "secret", "token", "authorization"}
+
+// loggingTransport writes every request and response to the instellar_http
+// tflog subsystem with sensitive values redacted.
+type loggingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := tflog.NewSubsystem(req.Context(), LoggingSubsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_INSTELLAR_HTTP"))
+
+	requestBody, err := peekRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tflog.SubsystemTrace(ctx, LoggingSubsystem, "Sending HTTP request", map[string]any{
+		"http_method":          req.Method,
+		"http_path":            req.URL.Path,
+		"http_request_headers": redactHeaders(req.Header),
+		"http_request_body":    redactBody(req.Header.Get("Content-Type"), requestBody),
+	})
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(start)
+
+	if err != nil {
+		tflog.SubsystemDebug(ctx, LoggingSubsystem, "HTTP request failed", map[string]any{
+			"http_method":     req.Method,
+			"http_path":       req.URL.Path,
+			"http_latency_ms": latency.Milliseconds(),
+			"error":           err.Error(),
+		})
+
+		return nil, err
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	tflog.SubsystemDebug(ctx, LoggingSubsystem, "Received HTTP response", map[string]any{
+		"http_method":     req.Method,
+		"http_path":       req.URL.Path,
+		"http_status":     resp.StatusCode,
+		"http_latency_ms": latency.Milliseconds(),
+	})
+
+	tflog.SubsystemTrace(ctx, LoggingSubsystem, "Received HTTP response body", map[string]any{
+		"http_method":        req.Method,
+		"http_path":          req.URL.Path,
+		"http_status":        resp.StatusCode,
+		"http_response_body": redactBody(resp.Header.Get("Content-Type"), responseBody),
+	})
+
+	return resp, nil
+}
+
+// peekRequestBody returns the request body while leaving it readable for the
+// next transport.
+func peekRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+
+		return io.ReadAll(body)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+
+	for name, values := range header {
+		headers[name] = strings.Join(values, ", ")
+
+		if isSensitive(name) {
+			headers[name] = redacted
+		}
+	}
+
+	return headers
+}
+
+// redactBody renders a JSON or form encoded body with the values of sensitive
+// keys replaced. Other bodies are returned as is.
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return redacted
+		}
+
+		for key := range form {
+			if isSensitive(key) {
+				form[key] = []string{redacted}
+			}
+		}
+
+		return form.Encode()
+	}
+
+	var document any
+
+	if err := json.Unmarshal(body, &document); err != nil {
+		return string(body)
+	}
+
+	redacted, err := json.Marshal(redactValue(document))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(redacted)
+}
+
+func redactValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, nested := range value {
+			if isSensitive(key) {
+				value[key] = redacted
+				continue
+			}
+
+			value[key] = redactValue(nested)
+		}
+	case []any:
+		for i, nested := range value {
+			value[i] = redactValue(nested)
+		}
+	}
+
+	return value
+}
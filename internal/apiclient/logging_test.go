@@ -0,0 +1,72 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestLoggingTransportRedactsSecrets(t *testing.T) {
+	var output bytes.Buffer
+
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	client := newTestClient(t, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"attributes":{"slug":"pizza"}}}`))
+	})
+
+	if _, err := client.WithContext(ctx).GetCluster("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, secret := range []string{"auth-token", "session-token"} {
+		if strings.Contains(output.String(), secret) {
+			t.Fatalf("expected %s to be redacted, got %s", secret, output.String())
+		}
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatalf("unexpected error decoding logs: %s", err)
+	}
+
+	var responses []string
+
+	for _, entry := range entries {
+		if entry["@module"] != "provider."+LoggingSubsystem {
+			t.Errorf("expected entry in %s subsystem, got %v", LoggingSubsystem, entry["@module"])
+		}
+
+		if entry["@message"] == "Received HTTP response" {
+			responses = append(responses, fmt.Sprint(entry["http_path"]))
+		}
+	}
+
+	if len(responses) != 2 || responses[0] != authenticatePath || responses[1] != "/provision/clusters/1" {
+		t.Errorf("expected callback and cluster responses to be logged, got %v", responses)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	body := []byte(`{"component":{"credential":{"username":"app","password":"hunter2","secret_access_key":"aws"}},"tokens":[{"password_token":"abc"}]}`)
+	expected := `{"component":{"credential":{"password":"[REDACTED]","secret_access_key":"[REDACTED]","username":"app"}},"tokens":"[REDACTED]"}`
+
+	if redactedBody := redactBody("application/json", body); redactedBody != expected {
+		t.Errorf("expected %s, got %s", expected, redactedBody)
+	}
+
+	form := redactBody("application/x-www-form-urlencoded", []byte("client_id=ci&client_secret=shh&grant_type=client_credentials"))
+
+	if form != "client_id=ci&client_secret=%5BREDACTED%5D&grant_type=client_credentials" {
+		t.Errorf("expected client_secret to be redacted, got %s", form)
+	}
+
+	if plain := redactBody("text/plain", []byte("bad gateway")); plain != "bad gateway" {
+		t.Errorf("expected plain body to be kept, got %s", plain)
+	}
+}
@@ -0,0 +1,1045 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// instellar client = instc.
+	instc "github.com/upmaru/instellar-go"
+)
+
+// MemoryHost selects the in-memory backend instead of an Instellar API. A
+// file path may follow it, for example memory:///tmp/instellar.json, to keep
+// the objects across Terraform commands. Without a path the objects only
+// live as long as the provider process.
+const MemoryHost = "memory://"
+
+// IsMemoryHost reports whether host selects the in-memory backend.
+func IsMemoryHost(host string) bool {
+	return strings.HasPrefix(host, MemoryHost)
+}
+
+// Objects move through these states, one step every time they are read, so
+// configurations can observe the same progression as on the Instellar API.
+// They are the states the fixtures of the instellar-go client record.
+var (
+	clusterStates   = []string{"connecting", "syncing", "healthy"}
+	nodeStates      = []string{"created", "healthy"}
+	uplinkStates    = []string{"created", "active"}
+	componentStates = []string{"active"}
+	balancerStates  = []string{"active"}
+	storageStates   = []string{"syncing", "healthy"}
+)
+
+const deletingState = "deleting"
+
+var (
+	memoryBackendsMu sync.Mutex
+	memoryBackends   = map[string]*memoryBackend{}
+)
+
+// memoryBackend answers the requests of the instellar client the way the
+// Instellar API does. Every provider instance configured with the same host
+// in a process shares one backend.
+type memoryBackend struct {
+	mu   sync.Mutex
+	file string
+	data memoryData
+}
+
+// memoryData holds the objects of a memoryBackend. It is written as is to
+// the backend file.
+type memoryData struct {
+	LastID     int                         `json:"last_id"`
+	Clusters   map[string]*instc.Cluster   `json:"clusters"`
+	Nodes      map[string]*instc.Node      `json:"nodes"`
+	Uplinks    map[string]*instc.Uplink    `json:"uplinks"`
+	Components map[string]*instc.Component `json:"components"`
+	Balancers  map[string]*instc.Balancer  `json:"balancers"`
+	Storages   map[string]*instc.Storage   `json:"storages"`
+	// Timestamps holds the times objects were created and updated at, keyed
+	// by the collection and id of the object such as "clusters/1".
+	Timestamps map[string]*memoryTimestamps `json:"timestamps"`
+	// InsterraComponents holds the insterra component ids objects were
+	// created with, which the instellar client does not decode, keyed like
+	// Timestamps.
+	InsterraComponents map[string]int `json:"insterra_components"`
+}
+
+type memoryTimestamps struct {
+	InsertedAt time.Time `json:"inserted_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func newMemoryTransport(host string) (http.RoundTripper, error) {
+	memoryBackendsMu.Lock()
+	defer memoryBackendsMu.Unlock()
+
+	backend, ok := memoryBackends[host]
+
+	if !ok {
+		backend = &memoryBackend{file: strings.TrimPrefix(host, MemoryHost)}
+
+		if err := backend.load(); err != nil {
+			return nil, err
+		}
+
+		memoryBackends[host] = backend
+	}
+
+	return &memoryTransport{host: host, backend: backend}, nil
+}
+
+// memoryTransport hands requests to a memoryBackend instead of sending them
+// over the network.
+type memoryTransport struct {
+	host    string
+	backend *memoryBackend
+}
+
+func (t *memoryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+
+	if req.Body != nil {
+		var err error
+
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	path := strings.TrimPrefix(req.URL.String(), t.host)
+	path = strings.Trim(strings.SplitN(path, "?", 2)[0], "/")
+
+	status, document := t.backend.handle(req.Method, path, body)
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(encoded)),
+		ContentLength: int64(len(encoded)),
+		Request:       req,
+	}, nil
+}
+
+func (b *memoryBackend) load() error {
+	b.data = memoryData{
+		Clusters:           map[string]*instc.Cluster{},
+		Nodes:              map[string]*instc.Node{},
+		Uplinks:            map[string]*instc.Uplink{},
+		Components:         map[string]*instc.Component{},
+		Balancers:          map[string]*instc.Balancer{},
+		Storages:           map[string]*instc.Storage{},
+		Timestamps:         map[string]*memoryTimestamps{},
+		InsterraComponents: map[string]int{},
+	}
+
+	if b.file == "" {
+		return nil
+	}
+
+	contents, err := os.ReadFile(b.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("reading in-memory backend file: %w", err)
+	}
+
+	if err := json.Unmarshal(contents, &b.data); err != nil {
+		return fmt.Errorf("decoding in-memory backend file %s: %w", b.file, err)
+	}
+
+	if b.data.Timestamps == nil {
+		b.data.Timestamps = map[string]*memoryTimestamps{}
+	}
+
+	if b.data.InsterraComponents == nil {
+		b.data.InsterraComponents = map[string]int{}
+	}
+
+	return nil
+}
+
+func (b *memoryBackend) save() error {
+	if b.file == "" {
+		return nil
+	}
+
+	contents, err := json.MarshalIndent(b.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temporary := b.file + ".tmp"
+
+	if err := os.WriteFile(temporary, contents, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(temporary, b.file)
+}
+
+func (b *memoryBackend) nextID() int {
+	b.data.LastID++
+
+	return b.data.LastID
+}
+
+// handle serves one request and returns the status and JSON document of the
+// response.
+func (b *memoryBackend) handle(method string, path string, body []byte) (int, any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	segments := strings.Split(path, "/")
+
+	if len(segments) < 2 || segments[0] != "provision" {
+		return notFound()
+	}
+
+	status, document := b.route(method, segments[1:], body)
+
+	if status < http.StatusBadRequest {
+		var err error
+
+		if document, err = b.stamp(method, segments[1:], document); err != nil {
+			return http.StatusInternalServerError, errorDocument("detail", err.Error())
+		}
+	}
+
+	if method != http.MethodGet && status < http.StatusBadRequest {
+		if err := b.save(); err != nil {
+			return http.StatusInternalServerError, errorDocument("detail", err.Error())
+		}
+	}
+
+	return status, document
+}
+
+func (b *memoryBackend) route(method string, segments []string, body []byte) (int, any) {
+	switch {
+	case match(segments, "automation", "callback") && method == http.MethodPost:
+		return http.StatusCreated, map[string]any{"data": map[string]any{"token": "memory-session"}}
+	case match(segments, "automation", "health") && method == http.MethodGet:
+		return http.StatusOK, map[string]any{
+			"data": map[string]any{"attributes": map[string]any{"status": "ok", "version": "memory"}},
+		}
+	case match(segments, "automation", "identity") && method == http.MethodGet:
+		return http.StatusOK, map[string]any{
+			"data": map[string]any{"attributes": map[string]any{"account": "memory", "organization": "memory"}},
+		}
+	case match(segments, "clusters") && method == http.MethodPost:
+		return b.createCluster(body)
+	case match(segments, "clusters") && method == http.MethodGet:
+		return collection(b.data.Clusters, func(cluster *instc.Cluster) bool {
+			return cluster.Data.Attributes.CurrentState != deletingState
+		})
+	case match(segments, "clusters", "*", "nodes") && method == http.MethodGet:
+		return collectionInCluster(b, segments[1], b.data.Nodes, func(node *instc.Node) (int, string) {
+			return node.Data.Attributes.ClusterID, node.Data.Attributes.CurrentState
+		})
+	case match(segments, "clusters", "*"):
+		return b.cluster(method, segments[1], body)
+	case match(segments, "clusters", "*", "nodes", "*") && method == http.MethodPut:
+		return b.putNode(segments[1], segments[3], body)
+	case match(segments, "nodes", "*"):
+		return b.node(method, segments[1])
+	case match(segments, "clusters", "*", "uplinks") && method == http.MethodPost:
+		return b.createUplink(segments[1], body)
+	case match(segments, "clusters", "*", "uplinks") && method == http.MethodGet:
+		return collectionInCluster(b, segments[1], b.data.Uplinks, func(uplink *instc.Uplink) (int, string) {
+			return uplink.Data.Attributes.ClusterID, uplink.Data.Attributes.CurrentState
+		})
+	case match(segments, "uplinks", "*"):
+		return b.uplink(method, segments[1], body)
+	case match(segments, "clusters", "*", "balancers") && method == http.MethodPost:
+		return b.createBalancer(segments[1], body)
+	case match(segments, "clusters", "*", "balancers") && method == http.MethodGet:
+		return collectionInCluster(b, segments[1], b.data.Balancers, func(balancer *instc.Balancer) (int, string) {
+			return balancer.Data.Attributes.ClusterID, balancer.Data.Attributes.CurrentState
+		})
+	case match(segments, "balancers", "*"):
+		return b.balancer(method, segments[1], body)
+	case match(segments, "components") && method == http.MethodPost:
+		return b.createComponent(body)
+	case match(segments, "components") && method == http.MethodGet:
+		return collection(b.data.Components, func(component *instc.Component) bool {
+			return component.Data.Attributes.CurrentState != deletingState
+		})
+	case match(segments, "components", "*"):
+		return b.component(method, segments[1], body)
+	case match(segments, "storages") && method == http.MethodPost:
+		return b.createStorage(body)
+	case match(segments, "storages") && method == http.MethodGet:
+		return collection(b.data.Storages, func(storage *instc.Storage) bool {
+			return storage.Data.Attributes.CurrentState != deletingState
+		})
+	case match(segments, "storages", "*"):
+		return b.storage(method, segments[1], body)
+	}
+
+	return notFound()
+}
+
+func (b *memoryBackend) createCluster(body []byte) (int, any) {
+	var req struct {
+		Cluster instc.ClusterParams `json:"cluster"`
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return badRequest(err)
+	}
+
+	params := req.Cluster
+	errs := required(map[string]string{"name": params.Name, "provider": params.Provider, "region": params.Region})
+	slug := slugify(params.Name)
+
+	for _, cluster := range b.data.Clusters {
+		if slug != "" && cluster.Data.Attributes.Slug == slug {
+			errs["name"] = append(errs["name"], "has already been taken")
+		}
+	}
+
+	if len(errs) > 0 {
+		return unprocessable(errs)
+	}
+
+	cluster := &instc.Cluster{}
+	attributes := &cluster.Data.Attributes
+	attributes.ID = b.nextID()
+	attributes.Name = params.Name
+	attributes.Slug = slug
+	attributes.Provider = params.Provider
+	attributes.Region = params.Region
+	attributes.Endpoint = params.CredentialEndpoint
+	attributes.CurrentState = clusterStates[0]
+
+	b.data.Clusters[strconv.Itoa(attributes.ID)] = cluster
+	b.insterraComponent("clusters", attributes.ID, params.InsterraComponentID)
+
+	return http.StatusCreated, cluster
+}
+
+func (b *memoryBackend) cluster(method string, id string, body []byte) (int, any) {
+	cluster, ok := b.data.Clusters[id]
+	if !ok {
+		return notFound()
+	}
+
+	attributes := &cluster.Data.Attributes
+
+	switch method {
+	case http.MethodGet:
+		if attributes.CurrentState == deletingState {
+			b.deleteCluster(id)
+			return notFound()
+		}
+
+		attributes.CurrentState = advance(clusterStates, attributes.CurrentState)
+	case http.MethodPatch:
+		var req struct {
+			Cluster instc.ClusterParams `json:"cluster"`
+		}
+
+		if err := json.Unmarshal(body, &req); err != nil {
+			return badRequest(err)
+		}
+
+		if req.Cluster.CredentialEndpoint != "" {
+			attributes.Endpoint = req.Cluster.CredentialEndpoint
+		}
+	case http.MethodDelete:
+		attributes.CurrentState = deletingState
+	default:
+		return notFound()
+	}
+
+	return http.StatusOK, cluster
+}
+
+// deleteCluster removes a cluster along with the nodes, uplinks and
+// balancers belonging to it.
+func (b *memoryBackend) deleteCluster(id string) {
+	clusterID, _ := strconv.Atoi(id)
+
+	delete(b.data.Clusters, id)
+
+	for key, node := range b.data.Nodes {
+		if node.Data.Attributes.ClusterID == clusterID {
+			delete(b.data.Nodes, key)
+		}
+	}
+
+	for key, uplink := range b.data.Uplinks {
+		if uplink.Data.Attributes.ClusterID == clusterID {
+			delete(b.data.Uplinks, key)
+		}
+	}
+
+	for key, balancer := range b.data.Balancers {
+		if balancer.Data.Attributes.ClusterID == clusterID {
+			delete(b.data.Balancers, key)
+		}
+	}
+}
+
+func (b *memoryBackend) putNode(clusterID string, slug string, body []byte) (int, any) {
+	cluster, ok := b.data.Clusters[clusterID]
+	if !ok {
+		return notFound()
+	}
+
+	var req struct {
+		Node instc.NodeParams `json:"node"`
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return badRequest(err)
+	}
+
+	if errs := required(map[string]string{"public_ip": req.Node.PublicIP}); len(errs) > 0 {
+		return unprocessable(errs)
+	}
+
+	for _, node := range b.data.Nodes {
+		attributes := &node.Data.Attributes
+
+		if attributes.ClusterID == cluster.Data.Attributes.ID && attributes.Slug == slug {
+			if attributes.PublicIP != req.Node.PublicIP {
+				attributes.PublicIP = req.Node.PublicIP
+				attributes.CurrentState = nodeStates[1]
+			}
+
+			return http.StatusOK, node
+		}
+	}
+
+	node := &instc.Node{}
+	attributes := &node.Data.Attributes
+	attributes.ID = b.nextID()
+	attributes.Slug = slug
+	attributes.PublicIP = req.Node.PublicIP
+	attributes.ClusterID = cluster.Data.Attributes.ID
+	attributes.CurrentState = nodeStates[0]
+
+	b.data.Nodes[strconv.Itoa(attributes.ID)] = node
+
+	return http.StatusCreated, node
+}
+
+func (b *memoryBackend) node(method string, id string) (int, any) {
+	node, ok := b.data.Nodes[id]
+	if !ok {
+		return notFound()
+	}
+
+	attributes := &node.Data.Attributes
+
+	switch method {
+	case http.MethodGet:
+		if attributes.CurrentState == deletingState {
+			delete(b.data.Nodes, id)
+			return notFound()
+		}
+
+		attributes.CurrentState = advance(nodeStates, attributes.CurrentState)
+	case http.MethodDelete:
+		attributes.CurrentState = deletingState
+	default:
+		return notFound()
+	}
+
+	return http.StatusOK, node
+}
+
+func (b *memoryBackend) createUplink(clusterID string, body []byte) (int, any) {
+	cluster, ok := b.data.Clusters[clusterID]
+	if !ok {
+		return notFound()
+	}
+
+	var req struct {
+		Uplink instc.UplinkSetupParams `json:"uplink"`
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return badRequest(err)
+	}
+
+	if errs := required(map[string]string{"channel_slug": req.Uplink.ChannelSlug}); len(errs) > 0 {
+		return unprocessable(errs)
+	}
+
+	uplink := &instc.Uplink{}
+	attributes := &uplink.Data.Attributes
+	attributes.ID = b.nextID()
+	attributes.InstallationID = b.nextID()
+	attributes.ClusterID = cluster.Data.Attributes.ID
+	attributes.ChannelSlug = req.Uplink.ChannelSlug
+	attributes.KitSlug = req.Uplink.KitSlug
+	attributes.CurrentState = uplinkStates[0]
+
+	b.data.Uplinks[strconv.Itoa(attributes.ID)] = uplink
+	b.listNodes(uplink)
+
+	return http.StatusCreated, uplink
+}
+
+func (b *memoryBackend) uplink(method string, id string, body []byte) (int, any) {
+	uplink, ok := b.data.Uplinks[id]
+	if !ok {
+		return notFound()
+	}
+
+	attributes := &uplink.Data.Attributes
+
+	switch method {
+	case http.MethodGet:
+		if attributes.CurrentState == deletingState {
+			delete(b.data.Uplinks, id)
+			return notFound()
+		}
+
+		attributes.CurrentState = advance(uplinkStates, attributes.CurrentState)
+	case http.MethodPatch:
+		var req struct {
+			Uplink instc.UplinkSetupParams `json:"uplink"`
+		}
+
+		if err := json.Unmarshal(body, &req); err != nil {
+			return badRequest(err)
+		}
+
+		if req.Uplink.ChannelSlug != "" && req.Uplink.ChannelSlug != attributes.ChannelSlug {
+			attributes.ChannelSlug = req.Uplink.ChannelSlug
+			attributes.CurrentState = uplinkStates[1]
+		}
+
+		if req.Uplink.KitSlug != "" {
+			attributes.KitSlug = req.Uplink.KitSlug
+		}
+	case http.MethodDelete:
+		attributes.CurrentState = deletingState
+	default:
+		return notFound()
+	}
+
+	b.listNodes(uplink)
+
+	return http.StatusOK, uplink
+}
+
+// listNodes sets the nodes of an uplink to the slugs of its cluster's nodes.
+func (b *memoryBackend) listNodes(uplink *instc.Uplink) {
+	nodes := []string{}
+
+	for _, node := range b.data.Nodes {
+		if node.Data.Attributes.ClusterID == uplink.Data.Attributes.ClusterID {
+			nodes = append(nodes, node.Data.Attributes.Slug)
+		}
+	}
+
+	uplink.Data.Attributes.Nodes = nodes
+}
+
+func (b *memoryBackend) createBalancer(clusterID string, body []byte) (int, any) {
+	cluster, ok := b.data.Clusters[clusterID]
+	if !ok {
+		return notFound()
+	}
+
+	var req struct {
+		Balancer instc.BalancerParams `json:"balancer"`
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return badRequest(err)
+	}
+
+	if errs := required(map[string]string{"name": req.Balancer.Name, "address": req.Balancer.Address}); len(errs) > 0 {
+		return unprocessable(errs)
+	}
+
+	balancer := &instc.Balancer{}
+	attributes := &balancer.Data.Attributes
+	attributes.ID = b.nextID()
+	attributes.Name = req.Balancer.Name
+	attributes.Address = req.Balancer.Address
+	attributes.ClusterID = cluster.Data.Attributes.ID
+	attributes.CurrentState = balancerStates[0]
+
+	b.data.Balancers[strconv.Itoa(attributes.ID)] = balancer
+
+	return http.StatusCreated, balancer
+}
+
+func (b *memoryBackend) balancer(method string, id string, body []byte) (int, any) {
+	balancer, ok := b.data.Balancers[id]
+	if !ok {
+		return notFound()
+	}
+
+	attributes := &balancer.Data.Attributes
+
+	switch method {
+	case http.MethodGet:
+		if attributes.CurrentState == deletingState {
+			delete(b.data.Balancers, id)
+			return notFound()
+		}
+
+		attributes.CurrentState = advance(balancerStates, attributes.CurrentState)
+	case http.MethodPatch:
+		var req struct {
+			Balancer instc.BalancerParams `json:"balancer"`
+		}
+
+		if err := json.Unmarshal(body, &req); err != nil {
+			return badRequest(err)
+		}
+
+		if req.Balancer.Name != "" {
+			attributes.Name = req.Balancer.Name
+		}
+
+		if req.Balancer.Address != "" {
+			attributes.Address = req.Balancer.Address
+		}
+	case http.MethodDelete:
+		attributes.CurrentState = deletingState
+	default:
+		return notFound()
+	}
+
+	return http.StatusOK, balancer
+}
+
+func (b *memoryBackend) createComponent(body []byte) (int, any) {
+	var req struct {
+		Component instc.ComponentParams `json:"component"`
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return badRequest(err)
+	}
+
+	params := req.Component
+	errs := required(map[string]string{"name": params.Name, "provider": params.Provider, "version": params.Version, "driver": params.Driver})
+
+	for _, clusterID := range params.ClusterIDS {
+		if _, ok := b.data.Clusters[strconv.Itoa(clusterID)]; !ok {
+			errs["cluster_ids"] = append(errs["cluster_ids"], fmt.Sprintf("cluster %d does not exist", clusterID))
+		}
+	}
+
+	if len(errs) > 0 {
+		return unprocessable(errs)
+	}
+
+	component := &instc.Component{}
+	attributes := &component.Data.Attributes
+	attributes.ID = b.nextID()
+	attributes.Slug = slugify(params.Name)
+	attributes.CurrentState = componentStates[0]
+	setComponent(component, params)
+
+	b.data.Components[strconv.Itoa(attributes.ID)] = component
+	b.insterraComponent("components", attributes.ID, params.InsterraComponentID)
+
+	return http.StatusCreated, component
+}
+
+func (b *memoryBackend) component(method string, id string, body []byte) (int, any) {
+	component, ok := b.data.Components[id]
+	if !ok {
+		return notFound()
+	}
+
+	attributes := &component.Data.Attributes
+
+	switch method {
+	case http.MethodGet:
+		if attributes.CurrentState == deletingState {
+			delete(b.data.Components, id)
+			return notFound()
+		}
+
+		attributes.CurrentState = advance(componentStates, attributes.CurrentState)
+	case http.MethodPatch:
+		var req struct {
+			Component instc.ComponentParams `json:"component"`
+		}
+
+		if err := json.Unmarshal(body, &req); err != nil {
+			return badRequest(err)
+		}
+
+		setComponent(component, req.Component)
+	case http.MethodDelete:
+		attributes.CurrentState = deletingState
+	default:
+		return notFound()
+	}
+
+	return http.StatusOK, component
+}
+
+// setComponent copies the attributes set in params to a component, as a
+// partial update does.
+func setComponent(component *instc.Component, params instc.ComponentParams) {
+	attributes := &component.Data.Attributes
+
+	if params.Provider != "" {
+		attributes.Provider = params.Provider
+	}
+
+	if params.Driver != "" {
+		attributes.Driver = params.Driver
+	}
+
+	if params.Version != "" {
+		attributes.Version = params.Version
+	}
+
+	if params.Channels != nil {
+		attributes.Channels = params.Channels
+	}
+
+	if params.ClusterIDS != nil {
+		attributes.ClusterIDS = params.ClusterIDS
+	}
+
+	if attributes.Channels == nil {
+		attributes.Channels = []string{}
+	}
+
+	if attributes.ClusterIDS == nil {
+		attributes.ClusterIDS = []int{}
+	}
+
+	if credential := params.Credential; credential != nil {
+		attributes.Credential.Username = credential.Username
+		attributes.Credential.Password = credential.Password
+		attributes.Credential.Resource = credential.Resource
+		attributes.Credential.Host = credential.Host
+		attributes.Credential.Port = credential.Port
+		attributes.Credential.Secure = credential.Secure
+		attributes.Credential.Certificate = nil
+
+		if credential.Certificate != "" {
+			certificate := credential.Certificate
+			attributes.Credential.Certificate = &certificate
+		}
+	}
+}
+
+func (b *memoryBackend) createStorage(body []byte) (int, any) {
+	var req struct {
+		Storage instc.StorageParams `json:"storage"`
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return badRequest(err)
+	}
+
+	params := req.Storage
+
+	if errs := required(map[string]string{"host": params.Host, "bucket": params.Bucket, "region": params.Region}); len(errs) > 0 {
+		return unprocessable(errs)
+	}
+
+	storage := &instc.Storage{}
+	attributes := &storage.Data.Attributes
+	attributes.ID = b.nextID()
+	attributes.CurrentState = storageStates[0]
+	setStorage(storage, params)
+
+	b.data.Storages[strconv.Itoa(attributes.ID)] = storage
+	b.insterraComponent("storages", attributes.ID, params.InsterraComponentID)
+
+	return http.StatusCreated, storage
+}
+
+func (b *memoryBackend) storage(method string, id string, body []byte) (int, any) {
+	storage, ok := b.data.Storages[id]
+	if !ok {
+		return notFound()
+	}
+
+	attributes := &storage.Data.Attributes
+
+	switch method {
+	case http.MethodGet:
+		if attributes.CurrentState == deletingState {
+			delete(b.data.Storages, id)
+			return notFound()
+		}
+
+		attributes.CurrentState = advance(storageStates, attributes.CurrentState)
+	case http.MethodPatch:
+		var req struct {
+			Storage instc.StorageParams `json:"storage"`
+		}
+
+		if err := json.Unmarshal(body, &req); err != nil {
+			return badRequest(err)
+		}
+
+		setStorage(storage, req.Storage)
+	case http.MethodDelete:
+		attributes.CurrentState = deletingState
+	default:
+		return notFound()
+	}
+
+	return http.StatusOK, storage
+}
+
+func setStorage(storage *instc.Storage, params instc.StorageParams) {
+	attributes := &storage.Data.Attributes
+
+	for field, value := range map[*string]string{
+		&attributes.Host:                      params.Host,
+		&attributes.Bucket:                    params.Bucket,
+		&attributes.Region:                    params.Region,
+		&attributes.CredentialAccessKeyID:     params.CredentialAccessKeyID,
+		&attributes.CredentialSecretAccessKey: params.CredentialSecretAccessKey,
+	} {
+		if value != "" {
+			*field = value
+		}
+	}
+}
+
+// collection answers with the objects kept by keep, ordered by id.
+func collection[T any](objects map[string]*T, keep func(*T) bool) (int, any) {
+	ids := make([]int, 0, len(objects))
+
+	for key := range objects {
+		id, _ := strconv.Atoi(key)
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+
+	data := []json.RawMessage{}
+
+	for _, id := range ids {
+		object := objects[strconv.Itoa(id)]
+
+		if !keep(object) {
+			continue
+		}
+
+		encoded, err := json.Marshal(object)
+		if err != nil {
+			return http.StatusInternalServerError, errorDocument("detail", err.Error())
+		}
+
+		document := struct {
+			Data json.RawMessage `json:"data"`
+		}{}
+
+		if err := json.Unmarshal(encoded, &document); err != nil {
+			return http.StatusInternalServerError, errorDocument("detail", err.Error())
+		}
+
+		data = append(data, document.Data)
+	}
+
+	return http.StatusOK, map[string]any{"data": data}
+}
+
+// collectionInCluster answers with the objects belonging to a cluster.
+// attributes returns the cluster id and state of an object.
+func collectionInCluster[T any](b *memoryBackend, clusterID string, objects map[string]*T, attributes func(*T) (int, string)) (int, any) {
+	cluster, ok := b.data.Clusters[clusterID]
+	if !ok {
+		return notFound()
+	}
+
+	return collection(objects, func(object *T) bool {
+		id, state := attributes(object)
+
+		return id == cluster.Data.Attributes.ID && state != deletingState
+	})
+}
+
+// match reports whether segments follow pattern, where "*" matches any
+// segment.
+func match(segments []string, pattern ...string) bool {
+	if len(segments) != len(pattern) {
+		return false
+	}
+
+	for i, segment := range pattern {
+		if segment != "*" && segment != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stamp records when the objects of document are created and updated, and
+// adds the times to their attributes the way the API reports them, along
+// with the insterra component id they were created with. The
+// collection of the objects is the last literal segment of the path.
+func (b *memoryBackend) stamp(method string, segments []string, document any) (any, error) {
+	kind := segments[len(segments)-1]
+
+	if len(segments)%2 == 0 {
+		kind = segments[len(segments)-2]
+	}
+
+	switch kind {
+	case "clusters", "nodes", "uplinks", "balancers", "components", "storages":
+	default:
+		return document, nil
+	}
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded struct {
+		Data json.RawMessage `json:"data"`
+	}
+
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+
+	if len(decoded.Data) == 0 {
+		return document, nil
+	}
+
+	var objects []map[string]any
+
+	if err := json.Unmarshal(decoded.Data, &objects); err != nil {
+		var object map[string]any
+
+		if err := json.Unmarshal(decoded.Data, &object); err != nil {
+			return nil, err
+		}
+
+		objects = []map[string]any{object}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, object := range objects {
+		attributes, ok := object["attributes"].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%v", kind, attributes["id"])
+		timestamps, found := b.data.Timestamps[key]
+
+		switch {
+		case !found && method != http.MethodGet:
+			timestamps = &memoryTimestamps{InsertedAt: now, UpdatedAt: now}
+			b.data.Timestamps[key] = timestamps
+		case !found:
+			continue
+		case method == http.MethodPatch || method == http.MethodPut:
+			timestamps.UpdatedAt = now
+		}
+
+		attributes["inserted_at"] = timestamps.InsertedAt.Format(time.RFC3339)
+		attributes["updated_at"] = timestamps.UpdatedAt.Format(time.RFC3339)
+
+		if insterraComponentID, ok := b.data.InsterraComponents[key]; ok {
+			attributes["insterra_component_id"] = insterraComponentID
+		}
+	}
+
+	if len(objects) == 1 && decoded.Data[0] == '{' {
+		return map[string]any{"data": objects[0]}, nil
+	}
+
+	return map[string]any{"data": objects}, nil
+}
+
+// insterraComponent records the insterra component id an object of kind was
+// created with, stamp adds it to the attributes of the object.
+func (b *memoryBackend) insterraComponent(kind string, id int, insterraComponentID int) {
+	if insterraComponentID != 0 {
+		b.data.InsterraComponents[fmt.Sprintf("%s/%d", kind, id)] = insterraComponentID
+	}
+}
+
+// advance returns the state following current, staying on the last state.
+func advance(states []string, current string) string {
+	for i, state := range states {
+		if state == current && i+1 < len(states) {
+			return states[i+1]
+		}
+	}
+
+	return current
+}
+
+var nonSlugCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(name string) string {
+	return strings.Trim(nonSlugCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func required(fields map[string]string) map[string][]string {
+	errs := map[string][]string{}
+
+	for field, value := range fields {
+		if value == "" {
+			errs[field] = []string{"can't be blank"}
+		}
+	}
+
+	return errs
+}
+
+func errorDocument(field string, message string) map[string]any {
+	return map[string]any{"errors": map[string]any{field: message}}
+}
+
+func notFound() (int, any) {
+	return http.StatusNotFound, errorDocument("detail", "Not Found")
+}
+
+func badRequest(err error) (int, any) {
+	return http.StatusBadRequest, errorDocument("detail", err.Error())
+}
+
+func unprocessable(errs map[string][]string) (int, any) {
+	return http.StatusUnprocessableEntity, map[string]any{"errors": errs}
+}
@@ -0,0 +1,170 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	instc "github.com/upmaru/instellar-go"
+)
+
+func newMemoryClient(t *testing.T) (*instc.Client, string) {
+	t.Helper()
+
+	host := MemoryHost + filepath.Join(t.TempDir(), "instellar.json")
+
+	client, err := New(host, StaticToken(""), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return client.WithContext(context.Background()), host
+}
+
+func createMemoryCluster(t *testing.T, api *instc.Client) string {
+	t.Helper()
+
+	cluster, err := api.CreateCluster(instc.ClusterParams{
+		Name:               "Pizza Cluster",
+		Provider:           "aws",
+		Region:             "ap-southeast-1",
+		CredentialEndpoint: "127.0.0.1:8443",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if cluster.Data.Attributes.Slug != "pizza-cluster" || cluster.Data.Attributes.CurrentState != "connecting" {
+		t.Errorf("unexpected cluster: %+v", cluster.Data.Attributes)
+	}
+
+	return strconv.Itoa(cluster.Data.Attributes.ID)
+}
+
+func TestMemoryBackendMovesThroughStates(t *testing.T) {
+	api, _ := newMemoryClient(t)
+	clusterID := createMemoryCluster(t, api)
+
+	var states []string
+
+	for i := 0; i < 5; i++ {
+		cluster, err := api.GetCluster(clusterID)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		states = append(states, cluster.Data.Attributes.CurrentState)
+	}
+
+	if expected := []string{"syncing", "healthy", "healthy", "healthy", "healthy"}; !equalStrings(states, expected) {
+		t.Errorf("expected states %v, got %v", expected, states)
+	}
+
+	if _, err := api.DeleteCluster(clusterID); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := api.GetCluster(clusterID); err == nil || !strings.Contains(err.Error(), "status: 404") {
+		t.Fatalf("expected deleted cluster to be gone, got %v", err)
+	}
+}
+
+func TestMemoryBackendNodesAndUplinks(t *testing.T) {
+	api, _ := newMemoryClient(t)
+	clusterID := createMemoryCluster(t, api)
+
+	node, err := api.CreateNode(clusterID, "pizza-node-01", instc.NodeParams{PublicIP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	updated, err := api.UpdateNode(clusterID, "pizza-node-01", instc.NodeParams{PublicIP: "10.0.0.2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if updated.Data.Attributes.ID != node.Data.Attributes.ID || updated.Data.Attributes.PublicIP != "10.0.0.2" {
+		t.Errorf("expected node to be updated in place, got %+v", updated.Data.Attributes)
+	}
+
+	uplink, err := api.CreateUplink(clusterID, instc.UplinkSetupParams{ChannelSlug: "develop"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if nodes := uplink.Data.Attributes.Nodes; len(nodes) != 1 || nodes[0] != "pizza-node-01" {
+		t.Errorf("expected uplink to list the cluster nodes, got %v", nodes)
+	}
+
+	if _, err := api.CreateUplink("404", instc.UplinkSetupParams{ChannelSlug: "develop"}); err == nil {
+		t.Errorf("expected uplink on unknown cluster to fail")
+	}
+}
+
+func TestMemoryBackendValidatesParams(t *testing.T) {
+	client, err := New(MemoryHost+filepath.Join(t.TempDir(), "instellar.json"), StaticToken(""), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, client.api.HostURL+"/provision/components", nil)
+
+	var apiErr *APIError
+
+	if err := do(client.WithContext(context.Background()).HTTPClient, req, nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected bad request without body, got %v", err)
+	}
+
+	_, err = client.WithContext(context.Background()).CreateComponent(instc.ComponentParams{Name: "pizza-db", ClusterIDS: []int{404}})
+
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+
+	for _, field := range []string{"provider", "version", "driver", "cluster_ids"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected %s to be reported in %s", field, err)
+		}
+	}
+}
+
+func TestMemoryBackendPersistsToFile(t *testing.T) {
+	api, host := newMemoryClient(t)
+	clusterID := createMemoryCluster(t, api)
+
+	memoryBackendsMu.Lock()
+	delete(memoryBackends, host)
+	memoryBackendsMu.Unlock()
+
+	client, err := New(host, StaticToken(""), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cluster, err := client.WithContext(context.Background()).GetCluster(clusterID)
+	if err != nil {
+		t.Fatalf("expected cluster to be loaded from file, got %s", err)
+	}
+
+	if cluster.Data.Attributes.Name != "Pizza Cluster" {
+		t.Errorf("unexpected cluster: %+v", cluster.Data.Attributes)
+	}
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,92 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// clientCredentials fetches access tokens from an OAuth2 token endpoint using
+// the client credentials grant. Access tokens are sent to the API as is.
+type clientCredentials struct {
+	clientID     string
+	clientSecret string
+	tokenURL     string
+
+	// transport is set by New so that token requests share the TLS, proxy,
+	// timeout and retry settings of API requests, without their headers.
+	transport http.RoundTripper
+}
+
+type accessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// ClientCredentials returns a source of OAuth2 access tokens obtained with the
+// client credentials grant.
+func ClientCredentials(clientID string, clientSecret string, tokenURL string) TokenSource {
+	return &clientCredentials{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		tokenURL:     tokenURL,
+		transport:    http.DefaultTransport,
+	}
+}
+
+func (c *clientCredentials) Token(ctx context.Context) (Token, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	requestedAt := time.Now()
+
+	resp, err := (&http.Client{Transport: c.transport}).Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("unable to fetch access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Token{}, fmt.Errorf("unable to fetch access token: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return Token{}, fmt.Errorf("unable to fetch access token: status: %d body: %s", resp.StatusCode, body)
+	}
+
+	var accessToken accessTokenResponse
+
+	if err := json.Unmarshal(body, &accessToken); err != nil {
+		return Token{}, fmt.Errorf("unable to decode access token: %w", err)
+	}
+
+	if accessToken.AccessToken == "" {
+		return Token{}, fmt.Errorf("token endpoint returned an empty access token")
+	}
+
+	token := Token{Value: accessToken.AccessToken, Bearer: true}
+
+	if accessToken.ExpiresIn > 0 {
+		token.ExpiresAt = requestedAt.Add(time.Duration(accessToken.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
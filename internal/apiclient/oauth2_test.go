@@ -0,0 +1,142 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// tokenEndpoint stands in for an OAuth2 authorization server issuing access
+// tokens that expire after expiresIn seconds.
+func tokenEndpoint(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var issued int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+
+		if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_id") != "terraform" || r.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+
+		n := atomic.AddInt32(&issued, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"access-%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, &issued
+}
+
+func TestClientCredentialsSendsAccessToken(t *testing.T) {
+	tokenServer, issued := tokenEndpoint(t, 3600)
+	server, exchanges := sessionServer(t)
+
+	client, err := New(server.URL, ClientCredentials("terraform", "secret", tokenServer.URL), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		cluster, err := client.WithContext(context.Background()).GetCluster("1")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if cluster.Data.Attributes.Slug != "Bearer access-1" {
+			t.Errorf("expected access token to be sent, got %q", cluster.Data.Attributes.Slug)
+		}
+	}
+
+	if *issued != 1 {
+		t.Errorf("expected a single access token to be issued, got %d", *issued)
+	}
+
+	if *exchanges != 0 {
+		t.Errorf("expected access token not to be exchanged, got %d exchanges", *exchanges)
+	}
+}
+
+func TestClientCredentialsRefreshesExpiredAccessToken(t *testing.T) {
+	tokenServer, issued := tokenEndpoint(t, 1)
+	server, _ := sessionServer(t)
+
+	client, err := New(server.URL, ClientCredentials("terraform", "secret", tokenServer.URL), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := client.WithContext(context.Background()).GetCluster("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cluster, err := client.WithContext(context.Background()).GetCluster("1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if cluster.Data.Attributes.Slug != "Bearer access-2" {
+		t.Errorf("expected refreshed access token, got %q", cluster.Data.Attributes.Slug)
+	}
+
+	if *issued != 2 {
+		t.Errorf("expected two access tokens to be issued, got %d", *issued)
+	}
+}
+
+func TestClientCredentialsReportsRejectedClient(t *testing.T) {
+	tokenServer, _ := tokenEndpoint(t, 3600)
+
+	_, err := ClientCredentials("terraform", "wrong", tokenServer.URL).Token(context.Background())
+
+	if err == nil {
+		t.Fatalf("expected rejected client credentials to fail")
+	}
+}
+
+func TestClientCredentialsSendsNoAPIHeadersToTokenEndpoint(t *testing.T) {
+	var received http.Header
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"access-1","token_type":"Bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	server, _ := sessionServer(t)
+
+	opts := Options{
+		Headers:      map[string]string{"X-Team": "platform"},
+		UserAgent:    "terraform-provider-instellar/test",
+		Organization: "acme",
+	}
+
+	client, err := New(server.URL, ClientCredentials("terraform", "secret", tokenServer.URL), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := client.WithContext(WithOrganization(context.Background(), "other")).GetCluster("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, name := range []string{"X-Team", OrganizationHeader} {
+		if value := received.Get(name); value != "" {
+			t.Errorf("expected %s not to be sent to the token endpoint, got %q", name, value)
+		}
+	}
+
+	if received.Get("User-Agent") == opts.UserAgent {
+		t.Errorf("expected the provider user agent not to be sent to the token endpoint")
+	}
+}
@@ -0,0 +1,218 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+
+	// instellar client = instc.
+	instc "github.com/upmaru/instellar-go"
+)
+
+// Reported holds the times the API reports for an object, which the
+// instellar client does not decode. They are read from the same document as
+// the object.
+type Reported struct {
+	InsertedAt string `json:"inserted_at"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// Timestamps parses the times the object was created and last updated at.
+func (r Reported) Timestamps() (Timestamps, error) {
+	created := r.CreatedAt
+
+	if created == "" {
+		created = r.InsertedAt
+	}
+
+	createdAt, err := parseTimestamp(created)
+	if err != nil {
+		return Timestamps{}, err
+	}
+
+	updatedAt, err := parseTimestamp(r.UpdatedAt)
+	if err != nil {
+		return Timestamps{}, err
+	}
+
+	return Timestamps{CreatedAt: createdAt, UpdatedAt: updatedAt}, nil
+}
+
+// Cluster is a cluster along with the attributes the instellar client does
+// not decode.
+type Cluster struct {
+	instc.Cluster
+	Reported
+	// InsterraComponentID is the insterra component a cluster is registered
+	// with, 0 when there is none.
+	InsterraComponentID int
+}
+
+// UnmarshalJSON decodes the document of a cluster.
+func (o *Cluster) UnmarshalJSON(document []byte) error {
+	if err := decodeReported(document, &o.Cluster, &o.Reported); err != nil {
+		return err
+	}
+
+	return decodeInsterraComponent(document, &o.InsterraComponentID)
+}
+
+// Node is a node along with the attributes the instellar client does not
+// decode.
+type Node struct {
+	instc.Node
+	Reported
+}
+
+// UnmarshalJSON decodes the document of a node.
+func (o *Node) UnmarshalJSON(document []byte) error {
+	return decodeReported(document, &o.Node, &o.Reported)
+}
+
+// Uplink is an uplink along with the attributes the instellar client does
+// not decode.
+type Uplink struct {
+	instc.Uplink
+	Reported
+}
+
+// UnmarshalJSON decodes the document of an uplink.
+func (o *Uplink) UnmarshalJSON(document []byte) error {
+	return decodeReported(document, &o.Uplink, &o.Reported)
+}
+
+// Balancer is a balancer along with the attributes the instellar client does
+// not decode.
+type Balancer struct {
+	instc.Balancer
+	Reported
+}
+
+// UnmarshalJSON decodes the document of a balancer.
+func (o *Balancer) UnmarshalJSON(document []byte) error {
+	return decodeReported(document, &o.Balancer, &o.Reported)
+}
+
+// Component is a component along with the attributes the instellar client
+// does not decode.
+type Component struct {
+	instc.Component
+	Reported
+	// InsterraComponentID is the insterra component a component is registered
+	// with, 0 when there is none.
+	InsterraComponentID int
+}
+
+// UnmarshalJSON decodes the document of a component.
+func (o *Component) UnmarshalJSON(document []byte) error {
+	if err := decodeReported(document, &o.Component, &o.Reported); err != nil {
+		return err
+	}
+
+	return decodeInsterraComponent(document, &o.InsterraComponentID)
+}
+
+// Storage is a storage along with the attributes the instellar client does
+// not decode.
+type Storage struct {
+	instc.Storage
+	Reported
+	// InsterraComponentID is the insterra component a storage is registered
+	// with, 0 when there is none.
+	InsterraComponentID int
+}
+
+// UnmarshalJSON decodes the document of a storage.
+func (o *Storage) UnmarshalJSON(document []byte) error {
+	if err := decodeReported(document, &o.Storage, &o.Reported); err != nil {
+		return err
+	}
+
+	return decodeInsterraComponent(document, &o.InsterraComponentID)
+}
+
+// decodeReported decodes document into object and the times the instellar
+// client leaves out into reported.
+func decodeReported(document []byte, object any, reported *Reported) error {
+	if err := json.Unmarshal(document, object); err != nil {
+		return err
+	}
+
+	return decodeAttributes(document, reported)
+}
+
+// decodeInsterraComponent decodes the insterra component the object in
+// document is registered with into id.
+func decodeInsterraComponent(document []byte, id *int) error {
+	attributes := struct {
+		InsterraComponentID int `json:"insterra_component_id"`
+	}{}
+
+	if err := decodeAttributes(document, &attributes); err != nil {
+		return err
+	}
+
+	*id = attributes.InsterraComponentID
+
+	return nil
+}
+
+// decodeAttributes decodes the attributes of the object in document into
+// attributes, a pointer.
+func decodeAttributes(document []byte, attributes any) error {
+	var object struct {
+		Data struct {
+			Attributes any `json:"attributes"`
+		} `json:"data"`
+	}
+
+	object.Data.Attributes = attributes
+
+	return json.Unmarshal(document, &object)
+}
+
+// GetCluster returns a cluster along with the attributes the instellar
+// client does not decode.
+func (c *Client) GetCluster(ctx context.Context, clusterID string) (*Cluster, error) {
+	return getObject[Cluster](ctx, c, "provision/clusters/"+clusterID)
+}
+
+// GetNode returns a node along with the attributes the instellar client does
+// not decode.
+func (c *Client) GetNode(ctx context.Context, nodeID string) (*Node, error) {
+	return getObject[Node](ctx, c, "provision/nodes/"+nodeID)
+}
+
+// GetUplink returns an uplink along with the attributes the instellar client
+// does not decode.
+func (c *Client) GetUplink(ctx context.Context, uplinkID string) (*Uplink, error) {
+	return getObject[Uplink](ctx, c, "provision/uplinks/"+uplinkID)
+}
+
+// GetBalancer returns a balancer along with the attributes the instellar
+// client does not decode.
+func (c *Client) GetBalancer(ctx context.Context, balancerID string) (*Balancer, error) {
+	return getObject[Balancer](ctx, c, "provision/balancers/"+balancerID)
+}
+
+// GetComponent returns a component along with the attributes the instellar
+// client does not decode.
+func (c *Client) GetComponent(ctx context.Context, componentID string) (*Component, error) {
+	return getObject[Component](ctx, c, "provision/components/"+componentID)
+}
+
+// GetStorage returns a storage along with the attributes the instellar
+// client does not decode.
+func (c *Client) GetStorage(ctx context.Context, storageID string) (*Storage, error) {
+	return getObject[Storage](ctx, c, "provision/storages/"+storageID)
+}
+
+func getObject[T any](ctx context.Context, c *Client, path string) (*T, error) {
+	object := new(T)
+
+	if err := c.get(ctx, path, object); err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
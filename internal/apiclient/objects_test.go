@@ -0,0 +1,54 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	// instellar client = instc.
+	instc "github.com/upmaru/instellar-go"
+)
+
+func TestGetDecodesInsterraComponent(t *testing.T) {
+	client := newTestClient(t, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"attributes":{"id":1,"slug":"pizza","insterra_component_id":7}}}`))
+	})
+
+	cluster, err := client.GetCluster(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if cluster.Data.Attributes.Slug != "pizza" || cluster.InsterraComponentID != 7 {
+		t.Errorf("unexpected cluster %+v", cluster)
+	}
+}
+
+func TestMemoryBackendReportsInsterraComponent(t *testing.T) {
+	api, host := newMemoryClient(t)
+
+	storage, err := api.CreateStorage(instc.StorageParams{
+		Host:                "s3.amazonaws.com",
+		Bucket:              "pizza",
+		Region:              "ap-southeast-1",
+		InsterraComponentID: 7,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client, err := New(host, StaticToken(""), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	reported, err := client.GetStorage(context.Background(), strconv.Itoa(storage.Data.Attributes.ID))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if reported.InsterraComponentID != 7 {
+		t.Errorf("expected insterra component 7, got %+v", reported)
+	}
+}
@@ -0,0 +1,26 @@
+package apiclient
+
+import "context"
+
+// OrganizationHeader selects the organization an API request acts on. The
+// API uses the default organization of the credential when it is missing.
+const OrganizationHeader = "X-Instellar-Organization"
+
+type organizationKey struct{}
+
+// WithOrganization returns a context whose requests act on organization
+// instead of the organization of the provider. An empty organization keeps
+// the organization of the provider.
+func WithOrganization(ctx context.Context, organization string) context.Context {
+	if organization == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, organizationKey{}, organization)
+}
+
+func organizationFrom(ctx context.Context) (string, bool) {
+	organization, ok := ctx.Value(organizationKey{}).(string)
+
+	return organization, ok
+}
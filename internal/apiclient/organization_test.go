@@ -0,0 +1,35 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOrganizationIsSentWithRequests(t *testing.T) {
+	client := newTestClient(t, Options{Organization: "upmaru", CacheTTL: time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"data":{"attributes":{"slug":%q}}}`, r.Header.Get(OrganizationHeader))
+	})
+
+	cases := map[string]context.Context{
+		"upmaru":  context.Background(),
+		"opsmaru": WithOrganization(context.Background(), "opsmaru"),
+	}
+
+	for expected, ctx := range cases {
+		// Read twice so that a response cached for the other organization
+		// would show up.
+		for i := 0; i < 2; i++ {
+			cluster, err := client.WithContext(ctx).GetCluster("1")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if cluster.Data.Attributes.Slug != expected {
+				t.Errorf("expected organization %s, got %q", expected, cluster.Data.Attributes.Slug)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// proxyStandIn answers requests the way an egress proxy would forward them and
+// records what it received.
+func proxyStandIn(t *testing.T) (*url.URL, *[]*http.Request) {
+	t.Helper()
+
+	var received []*http.Request
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Clone(context.Background()))
+
+		if r.URL.Path == "/provision/automation/callback" {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(authResponse))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"attributes":{"id":1,"slug":"pizza"}}}`))
+	}))
+
+	t.Cleanup(proxy.Close)
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return proxyURL, &received
+}
+
+func TestProxyURLRoutesEveryRequestThroughProxy(t *testing.T) {
+	proxyURL, received := proxyStandIn(t)
+
+	client, err := New("http://web.instellar.invalid", StaticToken("auth-token"), Options{
+		ProxyURL:  proxyURL,
+		Headers:   map[string]string{"X-Gateway-Route": "instellar"},
+		UserAgent: "terraform-provider-instellar/test",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cluster, err := client.WithContext(context.Background()).GetCluster("1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if cluster.Data.Attributes.Slug != "pizza" {
+		t.Errorf("expected cluster to be returned through the proxy, got %q", cluster.Data.Attributes.Slug)
+	}
+
+	if len(*received) != 2 {
+		t.Fatalf("expected authentication and cluster requests to reach the proxy, got %d", len(*received))
+	}
+
+	for _, req := range *received {
+		if req.Host != "web.instellar.invalid" {
+			t.Errorf("expected request for web.instellar.invalid, got %s", req.Host)
+		}
+
+		if req.Header.Get("X-Gateway-Route") != "instellar" {
+			t.Errorf("expected extra header on %s, got %q", req.URL.Path, req.Header.Get("X-Gateway-Route"))
+		}
+
+		if req.UserAgent() != "terraform-provider-instellar/test" {
+			t.Errorf("expected user agent on %s, got %q", req.URL.Path, req.UserAgent())
+		}
+	}
+}
+
+func TestHeadersDoNotOverrideClientHeaders(t *testing.T) {
+	proxyURL, received := proxyStandIn(t)
+
+	client, err := New("http://web.instellar.invalid", StaticToken("auth-token"), Options{
+		ProxyURL: proxyURL,
+		Headers:  map[string]string{"Authorization": "Bearer spoofed"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := client.WithContext(context.Background()).GetCluster("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	last := (*received)[len(*received)-1]
+
+	if last.Header.Get("Authorization") != "Bearer session-token" {
+		t.Errorf("expected session token to be kept, got %q", last.Header.Get("Authorization"))
+	}
+}
@@ -0,0 +1,40 @@
+package apiclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrReadOnly is returned when a resource is about to be modified through a
+// provider configured with read_only.
+var ErrReadOnly = errors.New("the provider is configured with read_only, refusing to modify Instellar resources")
+
+// Writable returns ErrReadOnly when the client must not modify resources.
+// Resources check it before issuing any create, update or delete request.
+func (c *Client) Writable() error {
+	if c.readOnly {
+		return ErrReadOnly
+	}
+
+	return nil
+}
+
+// readOnlyTransport rejects every request that is not a read, in case a
+// mutating call slips past Writable.
+type readOnlyTransport struct {
+	base http.RoundTripper
+}
+
+func (t *readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return t.base.RoundTrip(req)
+	}
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrReadOnly, req.Method, req.URL.Path)
+}
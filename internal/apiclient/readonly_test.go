@@ -0,0 +1,49 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	instc "github.com/upmaru/instellar-go"
+)
+
+func TestReadOnlyClientRefusesMutations(t *testing.T) {
+	var mutations int32
+
+	client := newTestClient(t, Options{ReadOnly: true}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			atomic.AddInt32(&mutations, 1)
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"attributes":{"id":1,"slug":"pizza"}}}`))
+	})
+
+	if err := client.Writable(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected read only error, got %v", err)
+	}
+
+	if _, err := client.WithContext(context.Background()).GetCluster("1"); err != nil {
+		t.Fatalf("expected reads to work, got %s", err)
+	}
+
+	_, err := client.WithContext(context.Background()).UpdateUplink("1", instc.UplinkSetupParams{ChannelSlug: "develop"})
+
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected read only error, got %v", err)
+	}
+
+	if mutations != 0 {
+		t.Errorf("expected no mutating request to reach the API, got %d", mutations)
+	}
+}
+
+func TestWritableClient(t *testing.T) {
+	client := newTestClient(t, Options{}, func(w http.ResponseWriter, r *http.Request) {})
+
+	if err := client.Writable(); err != nil {
+		t.Errorf("expected client to be writable, got %s", err)
+	}
+}
@@ -0,0 +1,107 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// APIError is returned when the Instellar API answers with an unexpected
+// status. Its message matches the errors of the instellar client.
+type APIError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("status: %d body: %s", e.StatusCode, e.Body)
+}
+
+// AsAPIError returns the APIError err stands for. The errors of the instellar
+// client only carry the status and body in their message, they are parsed
+// back into an APIError.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+
+	if err == nil {
+		return nil, false
+	}
+
+	rest, ok := strings.CutPrefix(err.Error(), "status: ")
+	if !ok {
+		return nil, false
+	}
+
+	code, body, ok := strings.Cut(rest, " body: ")
+	if !ok {
+		return nil, false
+	}
+
+	status, convErr := strconv.Atoi(code)
+	if convErr != nil {
+		return nil, false
+	}
+
+	return &APIError{StatusCode: status, Body: []byte(body)}, true
+}
+
+// IsNotFound reports whether err is the API answering that the requested
+// object does not exist.
+func IsNotFound(err error) bool {
+	apiErr, ok := AsAPIError(err)
+
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// get fetches path from the API and decodes the JSON response into out.
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	return c.getURL(ctx, fmt.Sprintf("%s/%s", c.api.HostURL, path), out)
+}
+
+// getURL fetches the absolute URL rawURL, which must be on the host of the
+// API, and decodes the JSON response into out.
+func (c *Client) getURL(ctx context.Context, rawURL string, out any) error {
+	api := c.WithContext(ctx)
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+
+	return do(api.HTTPClient, req, out)
+}
+
+// do sends req and decodes a successful JSON response into out.
+func do(client *http.Client, req *http.Request, out any) error {
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return &APIError{StatusCode: resp.StatusCode, Body: body}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,33 @@
+package apiclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestIsNotFound(t *testing.T) {
+	for _, tc := range []struct {
+		err      error
+		expected bool
+	}{
+		{err: fmt.Errorf("status: 404 body: %s", `{"errors":{"detail":"Not Found"}}`), expected: true},
+		{err: fmt.Errorf("reading cluster: %w", &APIError{StatusCode: http.StatusNotFound}), expected: true},
+		{err: fmt.Errorf("status: 500 body: %s", "boom"), expected: false},
+		{err: errors.New("dial tcp: connection refused"), expected: false},
+		{err: nil, expected: false},
+	} {
+		if got := IsNotFound(tc.err); got != tc.expected {
+			t.Errorf("expected IsNotFound(%v) to be %t", tc.err, tc.expected)
+		}
+	}
+}
+
+func TestAsAPIErrorParsesInstellarClientErrors(t *testing.T) {
+	apiErr, ok := AsAPIError(fmt.Errorf("status: 422 body: %s", `{"errors":{"name":["can't be blank"]}}`))
+
+	if !ok || apiErr.StatusCode != http.StatusUnprocessableEntity || string(apiErr.Body) != `{"errors":{"name":["can't be blank"]}}` {
+		t.Errorf("unexpected API error %+v", apiErr)
+	}
+}
@@ -0,0 +1,170 @@
+package apiclient
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const retryMinWait = 1 * time.Second
+
+// retryTransport retries requests that failed with a transient error using
+// exponential backoff with full jitter. A Retry-After header sent by the API
+// takes precedence over the computed backoff.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	minWait    time.Duration
+	maxWait    time.Duration
+}
+
+func newRetryTransport(base http.RoundTripper, maxRetries int, maxWait time.Duration) *retryTransport {
+	return &retryTransport{
+		base:       base,
+		maxRetries: maxRetries,
+		minWait:    retryMinWait,
+		maxWait:    maxWait,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+
+		if attempt >= t.maxRetries || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		wait, ok := t.backoff(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain the body so the connection can be reused by the next attempt.
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns how long to wait before the next attempt. It reports false
+// when the API asked for a longer pause than the configured maximum wait.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait, wait <= t.maxWait
+		}
+	}
+
+	ceiling := t.minWait << attempt
+	if ceiling <= 0 || ceiling > t.maxWait {
+		ceiling = t.maxWait
+	}
+
+	if ceiling <= 0 {
+		return 0, true
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling))) + 1, true
+}
+
+// rewind returns a request that can be sent for the given attempt, restoring
+// the body consumed by the previous attempt.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be replayed for retry")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Body = body
+
+	return clone, nil
+}
+
+// shouldRetry decides whether a request may safely be sent again. Idempotent
+// requests are retried on network errors and on gateway or throttling
+// responses. Mutating requests are only retried when the API cannot have acted
+// on them: the connection was never established or the request was throttled.
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		if isIdempotent(req.Method) {
+			return true
+		}
+
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(req.Method)
+	}
+
+	return false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an
+// HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+
+		return wait, true
+	}
+
+	return 0, false
+}
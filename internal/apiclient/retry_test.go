@@ -0,0 +1,163 @@
+package apiclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestRetryTransport(maxRetries int) *retryTransport {
+	transport := newRetryTransport(http.DefaultTransport, maxRetries, time.Second)
+	transport.minWait = time.Millisecond
+
+	return transport
+}
+
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if atomic.AddInt32(&calls, 1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+
+			w.WriteHeader(status)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func TestRetryTransportRetriesIdempotentRequests(t *testing.T) {
+	server, calls := flakyServer(t, 2, http.StatusBadGateway, nil)
+	client := &http.Client{Transport: newTestRetryTransport(3)}
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"node":{}}`))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	if string(body) != `{"node":{}}` {
+		t.Errorf("expected body to be replayed, got %q", body)
+	}
+
+	if *calls != 3 {
+		t.Errorf("expected 3 calls, got %d", *calls)
+	}
+}
+
+func TestRetryTransportGivesUpAfterMaxRetries(t *testing.T) {
+	server, calls := flakyServer(t, 10, http.StatusServiceUnavailable, nil)
+	client := &http.Client{Transport: newTestRetryTransport(2)}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", resp.StatusCode)
+	}
+
+	if *calls != 3 {
+		t.Errorf("expected 3 calls, got %d", *calls)
+	}
+}
+
+func TestRetryTransportDoesNotRetryMutatingRequestsOnGatewayErrors(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusBadGateway, nil)
+	client := &http.Client{Transport: newTestRetryTransport(3)}
+
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %d", resp.StatusCode)
+	}
+
+	if *calls != 1 {
+		t.Errorf("expected 1 call, got %d", *calls)
+	}
+}
+
+func TestRetryTransportRetriesThrottledMutatingRequests(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"0"}})
+	client := &http.Client{Transport: newTestRetryTransport(3)}
+
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	if *calls != 2 {
+		t.Errorf("expected 2 calls, got %d", *calls)
+	}
+}
+
+func TestRetryTransportStopsWhenRetryAfterExceedsMaxWait(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"120"}})
+	client := &http.Client{Transport: newTestRetryTransport(3)}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", resp.StatusCode)
+	}
+
+	if *calls != 1 {
+		t.Errorf("expected 1 call, got %d", *calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if wait, ok := retryAfter("7"); !ok || wait != 7*time.Second {
+		t.Errorf("expected 7s, got %s (%t)", wait, ok)
+	}
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+
+	if wait, ok := retryAfter(date); !ok || wait <= 0 || wait > time.Minute {
+		t.Errorf("expected wait within a minute, got %s (%t)", wait, ok)
+	}
+
+	if _, ok := retryAfter("soon"); ok {
+		t.Errorf("expected invalid header to be ignored")
+	}
+}
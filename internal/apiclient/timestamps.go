@@ -0,0 +1,34 @@
+package apiclient
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timestamps are the times the API reports an object was created and last
+// updated at. A zero time means the API did not report it.
+type Timestamps struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// naiveTimestamp is the layout of timestamps without a time zone, which the
+// API sends in UTC.
+const naiveTimestamp = "2006-01-02T15:04:05.999999999"
+
+func parseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return parsed.UTC(), nil
+	}
+
+	parsed, err := time.Parse(naiveTimestamp, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected timestamp %q", value)
+	}
+
+	return parsed.UTC(), nil
+}
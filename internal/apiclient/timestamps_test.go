@@ -0,0 +1,99 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTimestampsAreParsed(t *testing.T) {
+	testCases := map[string]struct {
+		attributes string
+		createdAt  time.Time
+		updatedAt  time.Time
+	}{
+		"rfc3339": {
+			attributes: `{"created_at":"2024-01-02T03:04:05+07:00","updated_at":"2024-01-03T00:00:00Z"}`,
+			createdAt:  time.Date(2024, 1, 1, 20, 4, 5, 0, time.UTC),
+			updatedAt:  time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+		},
+		"naive inserted_at": {
+			attributes: `{"inserted_at":"2024-01-02T03:04:05","updated_at":"2024-01-02T03:04:05.123456"}`,
+			createdAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			updatedAt:  time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		},
+		"missing": {
+			attributes: `{}`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var path string
+
+			client := newTestClient(t, Options{}, func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+
+				_, _ = w.Write([]byte(`{"data":{"attributes":` + testCase.attributes + `}}`))
+			})
+
+			cluster, err := client.GetCluster(context.Background(), "1")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if path != "/provision/clusters/1" {
+				t.Errorf("expected cluster 1 to be requested, got %s", path)
+			}
+
+			timestamps, err := cluster.Timestamps()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !timestamps.CreatedAt.Equal(testCase.createdAt) || !timestamps.UpdatedAt.Equal(testCase.updatedAt) {
+				t.Errorf("unexpected timestamps %+v", timestamps)
+			}
+		})
+	}
+}
+
+func TestTimestampsRejectUnexpectedFormat(t *testing.T) {
+	client := newTestClient(t, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"attributes":{"updated_at":"yesterday"}}}`))
+	})
+
+	node, err := client.GetNode(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("expected the node to be read despite its timestamp, got %s", err)
+	}
+
+	if _, err := node.Timestamps(); err == nil {
+		t.Fatal("expected an error for an unexpected timestamp")
+	}
+}
+
+func TestMemoryBackendReportsTimestamps(t *testing.T) {
+	api, host := newMemoryClient(t)
+	clusterID := createMemoryCluster(t, api)
+
+	client, err := New(host, StaticToken(""), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cluster, err := client.GetCluster(context.Background(), clusterID)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	timestamps, err := cluster.Timestamps()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if timestamps.CreatedAt.IsZero() || !timestamps.UpdatedAt.Equal(timestamps.CreatedAt) {
+		t.Errorf("expected creation time to be reported, got %+v", timestamps)
+	}
+}
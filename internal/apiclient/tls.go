@@ -0,0 +1,59 @@
+package apiclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidCACert     = errors.New("no valid PEM encoded certificate found in CA certificate")
+	ErrInvalidClientCert = errors.New("invalid client certificate or key")
+)
+
+// TLSOptions describe how the API host is trusted and how the provider
+// authenticates itself to hosts that require mutual TLS.
+type TLSOptions struct {
+	CACertPEM          string
+	ClientCertPEM      string
+	ClientKeyPEM       string
+	InsecureSkipVerify bool
+}
+
+// Config builds the TLS configuration used to reach the API host. It returns
+// nil when the system defaults apply.
+func (o TLSOptions) Config() (*tls.Config, error) {
+	if o.CACertPEM == "" && o.ClientCertPEM == "" && o.ClientKeyPEM == "" && !o.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.InsecureSkipVerify, //nolint:gosec // explicitly requested by the user.
+	}
+
+	if o.CACertPEM != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM([]byte(o.CACertPEM)) {
+			return nil, ErrInvalidCACert
+		}
+
+		config.RootCAs = pool
+	}
+
+	if o.ClientCertPEM != "" || o.ClientKeyPEM != "" {
+		certificate, err := tls.X509KeyPair([]byte(o.ClientCertPEM), []byte(o.ClientKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidClientCert, err)
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
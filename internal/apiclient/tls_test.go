@@ -0,0 +1,132 @@
+package apiclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func certificatePEM(certificate *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+}
+
+// selfSignedClientCertificate returns a PEM encoded certificate and key usable
+// for client authentication.
+func selfSignedClientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	return certificate, certificatePEM(certificate), keyPEM
+}
+
+func TestTLSOptionsDefaultsToSystemTrust(t *testing.T) {
+	config, err := TLSOptions{}.Config()
+
+	if err != nil || config != nil {
+		t.Fatalf("expected no TLS override, got %v, %v", config, err)
+	}
+}
+
+func TestTLSOptionsTrustsCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	config, err := TLSOptions{CACertPEM: certificatePEM(server.Certificate())}.Config()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resp, err := baseClient(Options{TLSConfig: config}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected custom CA to be trusted, got %s", err)
+	}
+	resp.Body.Close()
+
+	if _, err := baseClient(Options{}).Get(server.URL); err == nil {
+		t.Fatalf("expected unknown CA to be rejected")
+	}
+}
+
+func TestTLSOptionsPresentsClientCertificate(t *testing.T) {
+	clientCertificate, certPEM, keyPEM := selfSignedClientCertificate(t)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCertificate)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	config, err := TLSOptions{
+		CACertPEM:     certificatePEM(server.Certificate()),
+		ClientCertPEM: certPEM,
+		ClientKeyPEM:  keyPEM,
+	}.Config()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resp, err := baseClient(Options{TLSConfig: config}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected client certificate to be accepted, got %s", err)
+	}
+	resp.Body.Close()
+}
+
+func TestTLSOptionsRejectsInvalidPEM(t *testing.T) {
+	if _, err := (TLSOptions{CACertPEM: "not a certificate"}).Config(); !errors.Is(err, ErrInvalidCACert) {
+		t.Errorf("expected invalid CA error, got %v", err)
+	}
+
+	if _, err := (TLSOptions{ClientCertPEM: "not a certificate", ClientKeyPEM: "not a key"}).Config(); !errors.Is(err, ErrInvalidClientCert) {
+		t.Errorf("expected invalid client certificate error, got %v", err)
+	}
+}
+
+func baseClient(opts Options) *http.Client {
+	return &http.Client{Transport: baseTransport(opts)}
+}
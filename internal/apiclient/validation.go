@@ -0,0 +1,65 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// FieldErrors are the messages of a request the API rejected, by field.
+// Nested fields are joined with dots, for example credential.password.
+type FieldErrors map[string][]string
+
+// Fields returns the fields with errors in alphabetical order.
+func (e FieldErrors) Fields() []string {
+	fields := make([]string, 0, len(e))
+
+	for field := range e {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+// AsFieldErrors decodes the field errors of a request the API rejected with
+// 422 Unprocessable Entity, such as {"errors":{"name":["can't be blank"]}}.
+func AsFieldErrors(err error) (FieldErrors, bool) {
+	apiErr, ok := AsAPIError(err)
+	if !ok || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		return nil, false
+	}
+
+	document := struct {
+		Errors map[string]json.RawMessage `json:"errors"`
+	}{}
+
+	if err := json.Unmarshal(apiErr.Body, &document); err != nil || len(document.Errors) == 0 {
+		return nil, false
+	}
+
+	fieldErrors := FieldErrors{}
+	fieldErrors.decode("", document.Errors)
+
+	return fieldErrors, len(fieldErrors) > 0
+}
+
+func (e FieldErrors) decode(prefix string, errors map[string]json.RawMessage) {
+	for field, raw := range errors {
+		var (
+			messages []string
+			message  string
+			nested   map[string]json.RawMessage
+		)
+
+		switch {
+		case json.Unmarshal(raw, &messages) == nil:
+			e[prefix+field] = append(e[prefix+field], messages...)
+		case json.Unmarshal(raw, &message) == nil:
+			e[prefix+field] = append(e[prefix+field], message)
+		case json.Unmarshal(raw, &nested) == nil:
+			e.decode(prefix+field+".", nested)
+		}
+	}
+}
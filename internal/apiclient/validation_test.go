@@ -0,0 +1,41 @@
+package apiclient
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestAsFieldErrorsDecodesNestedFields(t *testing.T) {
+	err := fmt.Errorf("status: 422 body: %s", `{"errors":{"name":["can't be blank","is too short"],"credential":{"password":["can't be blank"]}}}`)
+
+	fieldErrors, ok := AsFieldErrors(err)
+	if !ok {
+		t.Fatalf("expected field errors in %s", err)
+	}
+
+	expected := FieldErrors{
+		"name":                {"can't be blank", "is too short"},
+		"credential.password": {"can't be blank"},
+	}
+
+	if !reflect.DeepEqual(fieldErrors, expected) {
+		t.Errorf("expected %v, got %v", expected, fieldErrors)
+	}
+
+	if fields := fieldErrors.Fields(); !reflect.DeepEqual(fields, []string{"credential.password", "name"}) {
+		t.Errorf("expected sorted fields, got %v", fields)
+	}
+}
+
+func TestAsFieldErrorsIgnoresOtherErrors(t *testing.T) {
+	for _, err := range []error{
+		fmt.Errorf("status: 404 body: %s", `{"errors":{"detail":"Not Found"}}`),
+		fmt.Errorf("status: 422 body: %s", "unprocessable"),
+		fmt.Errorf("dial tcp: connection refused"),
+	} {
+		if fieldErrors, ok := AsFieldErrors(err); ok {
+			t.Errorf("expected no field errors in %s, got %v", err, fieldErrors)
+		}
+	}
+}
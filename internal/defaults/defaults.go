@@ -0,0 +1,86 @@
+// Package defaults fills resource attributes left out of the configuration
+// with the values of the provider defaults block while planning.
+package defaults
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// String plans value for attribute when it is not configured. An attribute
+// that is neither configured nor defaulted is reported as missing.
+func String(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, attribute path.Path, value string) {
+	var configured types.String
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, attribute, &configured)...)
+
+	if resp.Diagnostics.HasError() || !configured.IsNull() {
+		return
+	}
+
+	if value == "" {
+		addMissingError(attribute, &resp.Diagnostics)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, attribute, types.StringValue(value))...)
+}
+
+// StringList plans values for a list attribute when it is not configured. A
+// nil values means the provider has no default for the attribute.
+func StringList(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, attribute path.Path, values []string) {
+	var configured types.List
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, attribute, &configured)...)
+
+	if resp.Diagnostics.HasError() || !configured.IsNull() {
+		return
+	}
+
+	if values == nil {
+		addMissingError(attribute, &resp.Diagnostics)
+		return
+	}
+
+	list, diags := types.ListValueFrom(ctx, types.StringType, values)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, attribute, list)...)
+}
+
+// RequiresReplace replaces the resource when the planned value of a string
+// attribute differs from its state. Attributes planned by String use it
+// instead of a RequiresReplace plan modifier, which runs before the defaults
+// are planned and would see the value as unknown.
+func RequiresReplace(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, attribute path.Path) {
+	if req.State.Raw.IsNull() || resp.Diagnostics.HasError() {
+		return
+	}
+
+	var planned, current types.String
+
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, attribute, &planned)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, attribute, &current)...)
+
+	if resp.Diagnostics.HasError() || planned.Equal(current) {
+		return
+	}
+
+	resp.RequiresReplace = append(resp.RequiresReplace, attribute)
+}
+
+func addMissingError(attribute path.Path, diags *diag.Diagnostics) {
+	diags.AddAttributeError(
+		attribute,
+		"Missing Attribute Value",
+		"The "+attribute.String()+" attribute must be set on the resource or in the defaults block of the provider.",
+	)
+}
@@ -0,0 +1,404 @@
+// Package doctor diagnoses the connection of the provider to the Instellar
+// API, from name resolution to the validation of the credential.
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/upmaru/terraform-provider-instellar/instellar"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// errSkipped is returned by checks that could not tell whether they
+// succeeded, along with a detail explaining why.
+var errSkipped = errors.New("skipped")
+
+// Status is the outcome of a Check.
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Check is the outcome of one step of the diagnosis.
+type Check struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Detail   string `json:"detail"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// Report collects the checks run against a host and what the API told about
+// itself and the credential.
+type Report struct {
+	Host         string  `json:"host"`
+	APIVersion   string  `json:"api_version,omitempty"`
+	Account      string  `json:"account,omitempty"`
+	Organization string  `json:"organization,omitempty"`
+	Checks       []Check `json:"checks"`
+}
+
+// Healthy reports whether no check failed.
+func (r *Report) Healthy() bool {
+	for _, check := range r.Checks {
+		if check.Status == StatusFailed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Main runs the doctor subcommand with the command line arguments following
+// it, writes the report to stdout and returns the exit code of the process.
+func Main(ctx context.Context, args []string, version string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	timeout := flags.Duration("timeout", 30*time.Second, "time allowed for the whole diagnosis")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	settings, diags := instellar.EnvironmentSettings(ctx, version)
+
+	var report *Report
+
+	if diags.HasError() {
+		report = &Report{Host: settings.Host}
+		report.add(configCheck(diags))
+		report.skip("the configuration is invalid", "dns", "tcp", "tls", "api", "token")
+	} else {
+		report = Run(ctx, settings)
+	}
+
+	var err error
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = report.Write(stdout)
+	}
+
+	if err != nil || !report.Healthy() {
+		return 1
+	}
+
+	return 0
+}
+
+func configCheck(diags diag.Diagnostics) Check {
+	var details []string
+
+	for _, d := range diags.Errors() {
+		details = append(details, d.Summary()+": "+d.Detail())
+	}
+
+	return Check{Name: "config", Status: StatusFailed, Detail: strings.Join(details, "; ")}
+}
+
+// Run diagnoses the connection described by settings. Each check only runs
+// when the checks it depends on succeeded.
+func Run(ctx context.Context, settings instellar.ClientSettings) *Report {
+	report := &Report{Host: settings.Host}
+
+	report.add(Check{Name: "config", Status: StatusOK, Detail: "using host " + settings.Host})
+
+	proxy := proxyFor(settings)
+
+	switch {
+	case apiclient.IsMemoryHost(settings.Host):
+		report.skip("the in-memory backend is not reached over the network", "dns", "tcp", "tls")
+	case proxy != nil:
+		report.skip("requests go through the proxy "+proxy.Redacted(), "dns", "tcp", "tls")
+	case !report.network(ctx, settings):
+		report.skip("the host cannot be reached", "api", "token")
+
+		return report
+	}
+
+	options := settings.Options
+	// Report failures right away instead of retrying them, and never answer
+	// from a cached response.
+	options.MaxRetries = 0
+	options.CacheTTL = 0
+
+	client, err := apiclient.New(settings.Host, settings.Tokens, options)
+	if err != nil {
+		report.add(Check{Name: "api", Status: StatusFailed, Detail: err.Error()})
+		report.skip("the client cannot be created", "token")
+
+		return report
+	}
+
+	report.run("api", func() (string, error) {
+		health, err := client.Health(ctx)
+		if apiclient.IsNotFound(err) {
+			return "the API does not report its health", errSkipped
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		report.APIVersion = health.Data.Attributes.Version
+
+		return fmt.Sprintf("status %s, version %s", health.Data.Attributes.Status, health.Data.Attributes.Version), nil
+	})
+
+	report.run("token", func() (string, error) {
+		err := client.Authenticate(ctx)
+
+		var apiErr *apiclient.APIError
+
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+			return "", fmt.Errorf("the credential was rejected (status %d)", apiErr.StatusCode)
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		identity, err := client.WhoAmI(ctx)
+		if err != nil {
+			return "the credential was accepted", nil
+		}
+
+		report.Account = identity.Data.Attributes.Account
+		report.Organization = identity.Data.Attributes.Organization
+
+		return fmt.Sprintf("account %s, organization %s", report.Account, report.Organization), nil
+	})
+
+	return report
+}
+
+// proxyFor returns the proxy requests to the host of settings go through,
+// nil when they reach the host directly.
+func proxyFor(settings instellar.ClientSettings) *url.URL {
+	if settings.Options.ProxyURL != nil {
+		return settings.Options.ProxyURL
+	}
+
+	hostURL, err := url.Parse(settings.Host)
+	if err != nil {
+		return nil
+	}
+
+	proxy, err := httpproxy.FromEnvironment().ProxyFunc()(hostURL)
+	if err != nil {
+		return nil
+	}
+
+	return proxy
+}
+
+// network resolves the host, connects to it and negotiates TLS for https
+// hosts. It returns false when any of them failed.
+func (r *Report) network(ctx context.Context, settings instellar.ClientSettings) bool {
+	hostURL, err := url.Parse(settings.Host)
+	if err != nil || hostURL.Hostname() == "" {
+		r.add(Check{Name: "dns", Status: StatusFailed, Detail: fmt.Sprintf("%q is not a valid URL", settings.Host)})
+		r.skip("the host is invalid", "tcp", "tls")
+
+		return false
+	}
+
+	hostname := hostURL.Hostname()
+	port := hostURL.Port()
+
+	if port == "" {
+		port = "443"
+
+		if hostURL.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	address := net.JoinHostPort(hostname, port)
+
+	if !r.run("dns", func() (string, error) {
+		addresses, err := net.DefaultResolver.LookupHost(ctx, hostname)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("%s resolves to %s", hostname, strings.Join(addresses, ", ")), nil
+	}) {
+		r.skip("the host cannot be resolved", "tcp", "tls")
+
+		return false
+	}
+
+	dialer := &net.Dialer{}
+
+	if !r.run("tcp", func() (string, error) {
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return "", err
+		}
+
+		defer conn.Close()
+
+		return "connected to " + conn.RemoteAddr().String(), nil
+	}) {
+		r.skip("the host cannot be reached", "tls")
+
+		return false
+	}
+
+	if hostURL.Scheme != "https" {
+		r.skip("the host is not served over https", "tls")
+
+		return true
+	}
+
+	return r.run("tls", func() (string, error) {
+		config := &tls.Config{}
+
+		if settings.Options.TLSConfig != nil {
+			config = settings.Options.TLSConfig.Clone()
+		}
+
+		config.ServerName = hostname
+
+		conn, err := (&tls.Dialer{NetDialer: dialer, Config: config}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return "", err
+		}
+
+		defer conn.Close()
+
+		tlsConn, ok := conn.(*tls.Conn)
+		if !ok {
+			return "negotiated", nil
+		}
+
+		state := tlsConn.ConnectionState()
+		detail := tlsVersion(state.Version)
+
+		if len(state.PeerCertificates) > 0 {
+			detail += ", " + certificate(state.PeerCertificates[0])
+		}
+
+		if config.InsecureSkipVerify {
+			detail += ", certificate not verified"
+		}
+
+		return detail, nil
+	})
+}
+
+// run times check and records its outcome under name. It returns whether
+// the check succeeded, a skipped check did not.
+func (r *Report) run(name string, check func() (string, error)) bool {
+	start := time.Now()
+	detail, err := check()
+
+	result := Check{Name: name, Status: StatusOK, Detail: detail, Duration: time.Since(start).Milliseconds()}
+
+	switch {
+	case errors.Is(err, errSkipped):
+		result.Status = StatusSkipped
+	case err != nil:
+		result.Status = StatusFailed
+		result.Detail = err.Error()
+	}
+
+	r.add(result)
+
+	return err == nil
+}
+
+func (r *Report) add(check Check) {
+	r.Checks = append(r.Checks, check)
+}
+
+func (r *Report) skip(reason string, names ...string) {
+	for _, name := range names {
+		r.add(Check{Name: name, Status: StatusSkipped, Detail: reason})
+	}
+}
+
+// Write prints the report in a human-readable form.
+func (r *Report) Write(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Instellar doctor report for %s\n\n", r.Host)
+
+	for _, check := range r.Checks {
+		fmt.Fprintf(&b, "  %-9s %-6s %s", "["+string(check.Status)+"]", check.Name, check.Detail)
+
+		if check.Status != StatusSkipped {
+			fmt.Fprintf(&b, " (%dms)", check.Duration)
+		}
+
+		b.WriteString("\n")
+	}
+
+	if r.APIVersion != "" || r.Account != "" {
+		b.WriteString("\n")
+	}
+
+	if r.APIVersion != "" {
+		fmt.Fprintf(&b, "API version:  %s\n", r.APIVersion)
+	}
+
+	if r.Account != "" {
+		fmt.Fprintf(&b, "Account:      %s\n", r.Account)
+		fmt.Fprintf(&b, "Organization: %s\n", r.Organization)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func tlsVersion(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+
+	return fmt.Sprintf("TLS 0x%04x", version)
+}
+
+func certificate(cert *x509.Certificate) string {
+	subject := cert.Subject.CommonName
+
+	if subject == "" && len(cert.DNSNames) > 0 {
+		subject = cert.DNSNames[0]
+	}
+
+	return fmt.Sprintf("certificate for %s issued by %s, expires %s",
+		subject, cert.Issuer.CommonName, cert.NotAfter.UTC().Format(time.RFC3339))
+}
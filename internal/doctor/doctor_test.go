@@ -0,0 +1,236 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/upmaru/terraform-provider-instellar/instellar"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+)
+
+func apiServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/automation/health"):
+			_, _ = w.Write([]byte(`{"data":{"attributes":{"status":"ok","version":"1.4.0"}}}`))
+		case strings.HasSuffix(r.URL.Path, "/automation/callback"):
+			body, _ := io.ReadAll(r.Body)
+
+			if !strings.Contains(string(body), `"auth_token":"`+token+`"`) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"data":{"token":"session"}}`))
+		case strings.HasSuffix(r.URL.Path, "/automation/identity"):
+			_, _ = w.Write([]byte(`{"data":{"attributes":{"account":"zacksiri","organization":"upmaru"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func settings(t *testing.T, server *httptest.Server, token string) instellar.ClientSettings {
+	t.Helper()
+
+	transport, ok := server.Client().Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("unexpected test server transport %T", server.Client().Transport)
+	}
+
+	return instellar.ClientSettings{
+		Host:    server.URL,
+		Tokens:  apiclient.StaticToken(token),
+		Options: apiclient.Options{TLSConfig: transport.TLSClientConfig},
+	}
+}
+
+func statuses(report *Report) string {
+	var parts []string
+
+	for _, check := range report.Checks {
+		parts = append(parts, check.Name+"="+string(check.Status))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestRunReportsHealthyConnection(t *testing.T) {
+	server := apiServer(t, "valid")
+
+	report := Run(context.Background(), settings(t, server, "valid"))
+
+	expected := "config=ok dns=ok tcp=ok tls=ok api=ok token=ok"
+
+	if got := statuses(report); got != expected {
+		t.Fatalf("expected %s, got %s: %+v", expected, got, report.Checks)
+	}
+
+	if report.APIVersion != "1.4.0" || report.Account != "zacksiri" || report.Organization != "upmaru" {
+		t.Errorf("expected API version and identity to be reported, got %+v", report)
+	}
+
+	var output bytes.Buffer
+
+	if err := report.Write(&output); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !strings.Contains(output.String(), "API version:  1.4.0") {
+		t.Errorf("expected API version in report, got %s", output.String())
+	}
+}
+
+func TestRunReportsRejectedToken(t *testing.T) {
+	server := apiServer(t, "valid")
+
+	report := Run(context.Background(), settings(t, server, "expired"))
+
+	if report.Healthy() {
+		t.Fatalf("expected report to be unhealthy, got %+v", report.Checks)
+	}
+
+	token := report.Checks[len(report.Checks)-1]
+
+	if token.Name != "token" || token.Status != StatusFailed || !strings.Contains(token.Detail, "rejected (status 401)") {
+		t.Errorf("expected rejected token check, got %+v", token)
+	}
+}
+
+func TestRunReportsUntrustedCertificate(t *testing.T) {
+	server := apiServer(t, "valid")
+
+	report := Run(context.Background(), instellar.ClientSettings{Host: server.URL, Tokens: apiclient.StaticToken("valid")})
+
+	expected := "config=ok dns=ok tcp=ok tls=failed api=skipped token=skipped"
+
+	if got := statuses(report); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestRunSkipsHealthMissingFromAPI(t *testing.T) {
+	backend := apiServer(t, "valid")
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/automation/health") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		backend.Config.Handler.ServeHTTP(w, r)
+	}))
+
+	t.Cleanup(server.Close)
+
+	report := Run(context.Background(), settings(t, server, "valid"))
+
+	expected := "config=ok dns=ok tcp=ok tls=ok api=skipped token=ok"
+
+	if got := statuses(report); got != expected {
+		t.Fatalf("expected %s, got %s: %+v", expected, got, report.Checks)
+	}
+
+	if !report.Healthy() {
+		t.Errorf("expected report to be healthy, got %+v", report.Checks)
+	}
+}
+
+func TestRunSkipsNetworkChecksBehindProxy(t *testing.T) {
+	server := apiServer(t, "valid")
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		defer upstream.Close()
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		conn, _, err := hijacker.Hijack()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		go func() { _, _ = io.Copy(upstream, conn) }()
+		_, _ = io.Copy(conn, upstream)
+	}))
+
+	t.Cleanup(proxy.Close)
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	proxied := settings(t, server, "valid")
+	proxied.Options.ProxyURL = proxyURL
+
+	report := Run(context.Background(), proxied)
+
+	expected := "config=ok dns=skipped tcp=skipped tls=skipped api=ok token=ok"
+
+	if got := statuses(report); got != expected {
+		t.Fatalf("expected %s, got %s: %+v", expected, got, report.Checks)
+	}
+
+	if !strings.Contains(report.Checks[1].Detail, proxyURL.Host) {
+		t.Errorf("expected the proxy in the skipped checks, got %+v", report.Checks[1])
+	}
+}
+
+func TestMainWritesJSONReport(t *testing.T) {
+	t.Setenv("INSTELLAR_HOST", apiclient.MemoryHost)
+	t.Setenv("INSTELLAR_AUTH_TOKEN", "")
+
+	var output bytes.Buffer
+
+	if code := Main(context.Background(), []string{"-json"}, "test", &output); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, output.String())
+	}
+
+	report := Report{}
+
+	if err := json.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatalf("unexpected error decoding report: %s", err)
+	}
+
+	if got := statuses(&report); got != "config=ok dns=skipped tcp=skipped tls=skipped api=ok token=ok" {
+		t.Errorf("unexpected checks %s", got)
+	}
+
+	if report.APIVersion != "memory" || report.Account != "memory" {
+		t.Errorf("expected in-memory identity, got %+v", report)
+	}
+}
@@ -0,0 +1,35 @@
+// Package fielderrors reports the fields the Instellar API rejected on the
+// attributes they come from.
+package fielderrors
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+)
+
+// Add adds a diagnostic for every field error of err and reports whether err
+// held any. attributes maps API fields to the attributes they come from,
+// errors of other fields are added without an attribute.
+func Add(diags *diag.Diagnostics, err error, attributes map[string]path.Path, summary string) bool {
+	fieldErrors, ok := apiclient.AsFieldErrors(err)
+	if !ok {
+		return false
+	}
+
+	for _, field := range fieldErrors.Fields() {
+		messages := strings.Join(fieldErrors[field], ", ")
+
+		attribute, ok := attributes[field]
+		if !ok {
+			diags.AddError(summary, "The Instellar API rejected "+field+": "+messages)
+			continue
+		}
+
+		diags.AddAttributeError(attribute, summary, "The Instellar API rejected this value: "+messages)
+	}
+
+	return true
+}
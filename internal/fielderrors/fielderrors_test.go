@@ -0,0 +1,40 @@
+package fielderrors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func TestAddMapsFieldsToAttributes(t *testing.T) {
+	var diags diag.Diagnostics
+
+	err := fmt.Errorf("status: 422 body: %s", `{"errors":{"credential_password":["is too short"],"token":["is invalid"]}}`)
+
+	if !Add(&diags, err, map[string]path.Path{"credential_password": path.Root("password_token")}, "Error creating instellar cluster") {
+		t.Fatalf("expected field errors to be added")
+	}
+
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", diags)
+	}
+
+	attributeDiag, ok := diags[0].(diag.DiagnosticWithPath)
+	if !ok || !attributeDiag.Path().Equal(path.Root("password_token")) || attributeDiag.Detail() != "The Instellar API rejected this value: is too short" {
+		t.Errorf("expected error on password_token, got %v", diags[0])
+	}
+
+	if diags[1].Detail() != "The Instellar API rejected token: is invalid" {
+		t.Errorf("expected error without attribute for unknown field, got %v", diags[1])
+	}
+}
+
+func TestAddIgnoresOtherErrors(t *testing.T) {
+	var diags diag.Diagnostics
+
+	if Add(&diags, fmt.Errorf("status: 500 body: boom"), nil, "Error creating instellar cluster") || len(diags) > 0 {
+		t.Errorf("expected other errors to be left to the caller, got %v", diags)
+	}
+}
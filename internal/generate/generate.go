@@ -0,0 +1,377 @@
+// Package generate writes the Terraform configuration and import blocks
+// bringing the objects of an existing Instellar account under management.
+package generate
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/upmaru/terraform-provider-instellar/instellar"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+	"github.com/zclconf/go-cty/cty"
+
+	// instellar client = instc.
+	instc "github.com/upmaru/instellar-go"
+)
+
+const header = `# Generated by terraform-provider-instellar generate.
+#
+# Secrets are not written to this file, set the variables declared at the
+# end of it before running terraform plan.
+
+`
+
+// Main runs the generate subcommand with the command line arguments
+// following it, writes the configuration to stdout and returns the exit code
+// of the process.
+func Main(ctx context.Context, args []string, version string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	organization := flags.String("organization", "", "organization to generate the configuration of, defaults to the one of the credential")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	settings, diags := instellar.EnvironmentSettings(ctx, version)
+
+	for _, d := range diags.Errors() {
+		fmt.Fprintf(stderr, "Error: %s\n\n%s\n", d.Summary(), d.Detail())
+	}
+
+	if diags.HasError() {
+		return 1
+	}
+
+	client, err := apiclient.New(settings.Host, settings.Tokens, settings.Options)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	config, err := Generate(ctx, client, *organization)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	if _, err := stdout.Write(config); err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// Generate lists every object of the organization and returns a resource
+// and an import block for each of them. An empty organization stands for
+// the one of the client.
+func Generate(ctx context.Context, client *apiclient.Client, organization string) ([]byte, error) {
+	ctx = apiclient.WithOrganization(ctx, organization)
+
+	g := &generator{
+		file:         hclwrite.NewEmptyFile(),
+		organization: organization,
+		labels:       map[string]bool{},
+		clusters:     map[int]string{},
+	}
+
+	clusters, err := client.ListClusters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing clusters: %w", err)
+	}
+
+	for _, cluster := range clusters {
+		g.cluster(cluster)
+	}
+
+	for _, cluster := range clusters {
+		clusterID := strconv.Itoa(cluster.Data.Attributes.ID)
+
+		nodes, err := client.ListNodes(ctx, clusterID)
+		if err != nil {
+			return nil, fmt.Errorf("listing nodes of cluster %s: %w", clusterID, err)
+		}
+
+		for _, node := range nodes {
+			g.node(node)
+		}
+
+		uplinks, err := client.ListUplinks(ctx, clusterID)
+		if err != nil {
+			return nil, fmt.Errorf("listing uplinks of cluster %s: %w", clusterID, err)
+		}
+
+		for _, uplink := range uplinks {
+			g.uplink(uplink)
+		}
+
+		balancers, err := client.ListBalancers(ctx, clusterID)
+		if err != nil {
+			return nil, fmt.Errorf("listing balancers of cluster %s: %w", clusterID, err)
+		}
+
+		for _, balancer := range balancers {
+			g.balancer(balancer)
+		}
+	}
+
+	components, err := client.ListComponents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing components: %w", err)
+	}
+
+	for _, component := range components {
+		g.component(component)
+	}
+
+	storages, err := client.ListStorages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing storages: %w", err)
+	}
+
+	for _, storage := range storages {
+		g.storage(storage)
+	}
+
+	for _, variable := range g.variables {
+		g.file.Body().AppendNewline()
+
+		block := g.file.Body().AppendNewBlock("variable", []string{variable})
+		block.Body().SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+		block.Body().SetAttributeValue("sensitive", cty.True)
+	}
+
+	return append([]byte(header), hclwrite.Format(g.file.Bytes())...), nil
+}
+
+// generator accumulates the blocks of the generated configuration.
+type generator struct {
+	file         *hclwrite.File
+	organization string
+	// labels holds the addresses already taken, resource labels are unique
+	// per resource type.
+	labels map[string]bool
+	// clusters maps cluster ids to the label of their resource, for the
+	// objects belonging to a cluster to refer to it.
+	clusters map[int]string
+	// variables are the sensitive variables the configuration refers to.
+	variables []string
+}
+
+func (g *generator) cluster(cluster apiclient.Cluster) {
+	attributes := cluster.Data.Attributes
+
+	label := g.label("instellar_cluster", attributes.Slug, "cluster")
+	g.clusters[attributes.ID] = label
+
+	body := g.resource("instellar_cluster", label, attributes.ID)
+	body.SetAttributeValue("name", cty.StringVal(attributes.Name))
+	body.SetAttributeValue("provider_name", cty.StringVal(attributes.Provider))
+	body.SetAttributeValue("region", cty.StringVal(attributes.Region))
+	body.SetAttributeValue("endpoint", cty.StringVal(attributes.Endpoint))
+	body.SetAttributeTraversal("password_token", g.variable("cluster_"+label+"_password_token"))
+	g.insterraComponentAttribute(body, cluster.InsterraComponentID)
+	g.organizationAttribute(body)
+}
+
+func (g *generator) node(node instc.Node) {
+	attributes := node.Data.Attributes
+
+	body := g.resource("instellar_node", g.label("instellar_node", attributes.Slug, "node"), attributes.ID)
+	body.SetAttributeValue("slug", cty.StringVal(attributes.Slug))
+	g.clusterAttribute(body, attributes.ClusterID)
+	body.SetAttributeValue("public_ip", cty.StringVal(attributes.PublicIP))
+	g.organizationAttribute(body)
+}
+
+func (g *generator) uplink(uplink instc.Uplink) {
+	attributes := uplink.Data.Attributes
+
+	body := g.resource("instellar_uplink", g.label("instellar_uplink", g.clusters[attributes.ClusterID], "uplink"), attributes.ID)
+	g.clusterAttribute(body, attributes.ClusterID)
+	body.SetAttributeValue("channel_slug", cty.StringVal(attributes.ChannelSlug))
+	body.SetAttributeValue("kit_slug", cty.StringVal(attributes.KitSlug))
+	g.organizationAttribute(body)
+}
+
+func (g *generator) balancer(balancer instc.Balancer) {
+	attributes := balancer.Data.Attributes
+
+	body := g.resource("instellar_balancer", g.label("instellar_balancer", attributes.Name, "balancer"), attributes.ID)
+	body.SetAttributeValue("name", cty.StringVal(attributes.Name))
+	body.SetAttributeValue("address", cty.StringVal(attributes.Address))
+	g.clusterAttribute(body, attributes.ClusterID)
+	g.organizationAttribute(body)
+}
+
+func (g *generator) component(component apiclient.Component) {
+	attributes := component.Data.Attributes
+
+	label := g.label("instellar_component", attributes.Slug, "component")
+
+	body := g.resource("instellar_component", label, attributes.ID)
+	body.SetAttributeValue("name", cty.StringVal(attributes.Slug))
+	body.SetAttributeValue("provider_name", cty.StringVal(attributes.Provider))
+	body.SetAttributeValue("driver", cty.StringVal(attributes.Driver))
+	body.SetAttributeValue("driver_version", cty.StringVal(attributes.Version))
+
+	var clusterIDs []hclwrite.Tokens
+
+	for _, clusterID := range attributes.ClusterIDS {
+		clusterIDs = append(clusterIDs, g.clusterReference(clusterID))
+	}
+
+	body.SetAttributeRaw("cluster_ids", hclwrite.TokensForTuple(clusterIDs))
+
+	channels := make([]cty.Value, len(attributes.Channels))
+
+	for i, channel := range attributes.Channels {
+		channels[i] = cty.StringVal(channel)
+	}
+
+	if len(channels) > 0 {
+		body.SetAttributeValue("channels", cty.ListVal(channels))
+	} else {
+		body.SetAttributeValue("channels", cty.ListValEmpty(cty.String))
+	}
+
+	g.insterraComponentAttribute(body, component.InsterraComponentID)
+	g.organizationAttribute(body)
+
+	credential := attributes.Credential
+
+	if credential.Username == "" && credential.Host == "" {
+		return
+	}
+
+	body.AppendNewline()
+
+	block := body.AppendNewBlock("credential", nil).Body()
+	block.SetAttributeValue("username", cty.StringVal(credential.Username))
+	block.SetAttributeTraversal("password", g.variable("component_"+label+"_password"))
+	block.SetAttributeValue("resource", cty.StringVal(credential.Resource))
+	block.SetAttributeValue("host", cty.StringVal(credential.Host))
+	block.SetAttributeValue("port", cty.NumberIntVal(int64(credential.Port)))
+
+	if credential.Certificate != nil {
+		block.SetAttributeValue("certificate", cty.StringVal(*credential.Certificate))
+	}
+
+	if credential.Secure {
+		block.SetAttributeValue("secure", cty.True)
+	}
+}
+
+func (g *generator) storage(storage apiclient.Storage) {
+	attributes := storage.Data.Attributes
+
+	label := g.label("instellar_storage", attributes.Bucket, "storage")
+
+	body := g.resource("instellar_storage", label, attributes.ID)
+	body.SetAttributeValue("host", cty.StringVal(attributes.Host))
+	body.SetAttributeValue("bucket", cty.StringVal(attributes.Bucket))
+	body.SetAttributeValue("region", cty.StringVal(attributes.Region))
+	body.SetAttributeValue("access_key_id", cty.StringVal(attributes.CredentialAccessKeyID))
+	body.SetAttributeTraversal("secret_access_key", g.variable("storage_"+label+"_secret_access_key"))
+	g.insterraComponentAttribute(body, storage.InsterraComponentID)
+	g.organizationAttribute(body)
+}
+
+// resource appends the import block of an object followed by its resource
+// block, and returns the body of the resource block.
+func (g *generator) resource(resourceType string, label string, id int) *hclwrite.Body {
+	body := g.file.Body()
+
+	if len(body.Blocks()) > 0 {
+		body.AppendNewline()
+	}
+
+	importID := strconv.Itoa(id)
+
+	if g.organization != "" {
+		importID = g.organization + "/" + importID
+	}
+
+	imports := body.AppendNewBlock("import", nil).Body()
+	imports.SetAttributeTraversal("to", hcl.Traversal{hcl.TraverseRoot{Name: resourceType}, hcl.TraverseAttr{Name: label}})
+	imports.SetAttributeValue("id", cty.StringVal(importID))
+
+	body.AppendNewline()
+
+	return body.AppendNewBlock("resource", []string{resourceType, label}).Body()
+}
+
+// clusterAttribute sets cluster_id to the id of the cluster resource, or to
+// the id itself for a cluster that was not generated.
+func (g *generator) clusterAttribute(body *hclwrite.Body, clusterID int) {
+	body.SetAttributeRaw("cluster_id", g.clusterReference(clusterID))
+}
+
+func (g *generator) clusterReference(clusterID int) hclwrite.Tokens {
+	label, ok := g.clusters[clusterID]
+	if !ok {
+		return hclwrite.TokensForValue(cty.StringVal(strconv.Itoa(clusterID)))
+	}
+
+	return hclwrite.TokensForTraversal(hcl.Traversal{
+		hcl.TraverseRoot{Name: "instellar_cluster"},
+		hcl.TraverseAttr{Name: label},
+		hcl.TraverseAttr{Name: "id"},
+	})
+}
+
+// insterraComponentAttribute sets insterra_component_id for objects created
+// with one.
+func (g *generator) insterraComponentAttribute(body *hclwrite.Body, insterraComponentID int) {
+	if insterraComponentID != 0 {
+		body.SetAttributeValue("insterra_component_id", cty.NumberIntVal(int64(insterraComponentID)))
+	}
+}
+
+func (g *generator) organizationAttribute(body *hclwrite.Body) {
+	if g.organization != "" {
+		body.SetAttributeValue("organization", cty.StringVal(g.organization))
+	}
+}
+
+// variable declares a sensitive variable and returns a reference to it.
+func (g *generator) variable(name string) hcl.Traversal {
+	g.variables = append(g.variables, name)
+
+	return hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: name}}
+}
+
+var nonIdentifierCharacters = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// label turns name into a resource label unique among the resources of
+// resourceType. fallback is used for names without any usable character.
+func (g *generator) label(resourceType string, name string, fallback string) string {
+	label := strings.Trim(nonIdentifierCharacters.ReplaceAllString(strings.ToLower(name), "_"), "_")
+
+	if label == "" {
+		label = fallback
+	}
+
+	if label[0] >= '0' && label[0] <= '9' {
+		label = fallback + "_" + label
+	}
+
+	unique := label
+
+	for i := 2; g.labels[resourceType+"."+unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", label, i)
+	}
+
+	g.labels[resourceType+"."+unique] = true
+
+	return unique
+}
@@ -0,0 +1,169 @@
+package generate
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+
+	// instellar client = instc.
+	instc "github.com/upmaru/instellar-go"
+)
+
+func TestGenerateWritesImportAndResourceBlocks(t *testing.T) {
+	client, err := apiclient.New(apiclient.MemoryHost+filepath.Join(t.TempDir(), "instellar.json"), apiclient.StaticToken(""), apiclient.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	api := client.WithContext(context.Background())
+
+	cluster, err := api.CreateCluster(instc.ClusterParams{
+		Name:                "pizza",
+		Provider:            "aws",
+		Region:              "ap-southeast-1",
+		CredentialEndpoint:  "127.0.0.1:8443",
+		InsterraComponentID: 7,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	clusterID := strconv.Itoa(cluster.Data.Attributes.ID)
+
+	if _, err := api.CreateNode(clusterID, "pizza-node-01", instc.NodeParams{PublicIP: "10.0.0.1"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := api.CreateUplink(clusterID, instc.UplinkSetupParams{ChannelSlug: "develop", KitSlug: "lite"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := api.CreateComponent(instc.ComponentParams{
+		Name:                "pizza-db",
+		Provider:            "aws",
+		Driver:              "database/postgresql",
+		Version:             "15.2",
+		ClusterIDS:          []int{cluster.Data.Attributes.ID},
+		Channels:            []string{"develop"},
+		InsterraComponentID: 7,
+		Credential: &instc.ComponentCredentialParams{
+			Username: "pizza",
+			Password: "pizza123",
+			Resource: "pizza_db",
+			Host:     "localhost",
+			Port:     5432,
+			Secure:   true,
+		},
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	config, err := Generate(context.Background(), client, "upmaru")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := header + `import {
+  to = instellar_cluster.pizza
+  id = "upmaru/1"
+}
+
+resource "instellar_cluster" "pizza" {
+  name                  = "pizza"
+  provider_name         = "aws"
+  region                = "ap-southeast-1"
+  endpoint              = "127.0.0.1:8443"
+  password_token        = var.cluster_pizza_password_token
+  insterra_component_id = 7
+  organization          = "upmaru"
+}
+
+import {
+  to = instellar_node.pizza_node_01
+  id = "upmaru/2"
+}
+
+resource "instellar_node" "pizza_node_01" {
+  slug         = "pizza-node-01"
+  cluster_id   = instellar_cluster.pizza.id
+  public_ip    = "10.0.0.1"
+  organization = "upmaru"
+}
+
+import {
+  to = instellar_uplink.pizza
+  id = "upmaru/3"
+}
+
+resource "instellar_uplink" "pizza" {
+  cluster_id   = instellar_cluster.pizza.id
+  channel_slug = "develop"
+  kit_slug     = "lite"
+  organization = "upmaru"
+}
+
+import {
+  to = instellar_component.pizza_db
+  id = "upmaru/5"
+}
+
+resource "instellar_component" "pizza_db" {
+  name                  = "pizza-db"
+  provider_name         = "aws"
+  driver                = "database/postgresql"
+  driver_version        = "15.2"
+  cluster_ids           = [instellar_cluster.pizza.id]
+  channels              = ["develop"]
+  insterra_component_id = 7
+  organization          = "upmaru"
+
+  credential {
+    username = "pizza"
+    password = var.component_pizza_db_password
+    resource = "pizza_db"
+    host     = "localhost"
+    port     = 5432
+    secure   = true
+  }
+}
+
+variable "cluster_pizza_password_token" {
+  type      = string
+  sensitive = true
+}
+
+variable "component_pizza_db_password" {
+  type      = string
+  sensitive = true
+}
+`
+
+	if string(config) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, config)
+	}
+}
+
+func TestLabelIsAValidUniqueIdentifier(t *testing.T) {
+	g := &generator{labels: map[string]bool{}}
+
+	for _, tc := range []struct {
+		name     string
+		expected string
+	}{
+		{name: "pizza-node-01", expected: "pizza_node_01"},
+		{name: "Pizza Node 01", expected: "pizza_node_01_2"},
+		{name: "01-pizza", expected: "node_01_pizza"},
+		{name: "---", expected: "node"},
+	} {
+		if label := g.label("instellar_node", tc.name, "node"); label != tc.expected {
+			t.Errorf("expected %s for %q, got %s", tc.expected, tc.name, label)
+		}
+	}
+
+	if label := g.label("instellar_uplink", "pizza-node-01", "uplink"); label != "pizza_node_01" {
+		t.Errorf("expected labels to be unique per resource type, got %s", label)
+	}
+}
@@ -0,0 +1,147 @@
+// Package operation holds the steps the create, read, update and delete
+// operations of every resource share.
+package operation
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+	"github.com/upmaru/terraform-provider-instellar/internal/fielderrors"
+	"github.com/upmaru/terraform-provider-instellar/internal/wait"
+)
+
+// Action is an operation of a resource.
+type Action int
+
+const (
+	Create Action = iota
+	Read
+	Update
+	Delete
+)
+
+var verbs = [...]struct{ verb, gerund string }{
+	Create: {"create", "creating"},
+	Read:   {"read", "reading"},
+	Update: {"update", "updating"},
+	Delete: {"delete", "deleting"},
+}
+
+// Timeout returns the duration an operation may take, such as the Create
+// method of the timeouts block.
+type Timeout func(ctx context.Context, defaultTimeout time.Duration) (time.Duration, diag.Diagnostics)
+
+// Kind describes the objects of a resource.
+type Kind struct {
+	// Name names the objects in diagnostics.
+	Name string
+	// Attributes maps the fields of API validation errors to the attributes
+	// they come from.
+	Attributes map[string]path.Path
+	// States are the states Create tells apart while waiting for an object.
+	States wait.States
+}
+
+func (k Kind) summary(action Action) string {
+	return "Error " + verbs[action].gerund + " " + k.Name
+}
+
+// Start checks that client may modify objects, and returns ctx bounded by
+// timeout and acting on organization. The returned cancel must be called
+// even when diags holds an error, in which case the operation stops.
+func (k Kind) Start(ctx context.Context, client *apiclient.Client, diags *diag.Diagnostics, action Action, timeout Timeout, organization types.String) (context.Context, context.CancelFunc) {
+	if err := client.Writable(); err != nil {
+		diags.AddError(k.summary(action), "Could not "+verbs[action].verb+" "+k.Name+": "+err.Error())
+		return ctx, func() {}
+	}
+
+	duration, timeoutDiags := timeout(ctx, wait.DefaultTimeout)
+	diags.Append(timeoutDiags...)
+
+	if diags.HasError() {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+
+	return apiclient.WithOrganization(ctx, organization.ValueString()), cancel
+}
+
+// Failed adds a diagnostic for err returned by the API on action, on the
+// attributes of the fields it rejected when it holds any, and reports
+// whether err is not nil.
+func (k Kind) Failed(diags *diag.Diagnostics, action Action, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if fielderrors.Add(diags, err, k.Attributes, k.summary(action)) {
+		return true
+	}
+
+	diags.AddError(
+		k.summary(action),
+		"Could not "+verbs[action].verb+" "+k.Name+", unexpected error: "+err.Error(),
+	)
+
+	return true
+}
+
+// ReadFailed adds a diagnostic for err returned by the API on reading the
+// object id, and reports whether err is not nil.
+func (k Kind) ReadFailed(diags *diag.Diagnostics, id string, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	diags.AddError(k.summary(Read), "Could not read "+k.Name+" id "+id+": "+err.Error())
+
+	return true
+}
+
+// Refresh handles err returned by the API on reading the object id for
+// Read: the object is removed from state when the API no longer finds it,
+// other errors are added to the diagnostics. It reports whether Read stops.
+func (k Kind) Refresh(ctx context.Context, resp *resource.ReadResponse, id string, err error) bool {
+	if apiclient.IsNotFound(err) {
+		tflog.Warn(ctx, "Instellar "+k.Name+" no longer exists, removing it from state", map[string]any{"id": id})
+		resp.State.RemoveResource(ctx)
+		return true
+	}
+
+	return k.ReadFailed(&resp.Diagnostics, id, err)
+}
+
+// AwaitReady waits until the object id created is ready, reading its state
+// with read, and sets currentState to the last state read.
+func (k Kind) AwaitReady(ctx context.Context, diags *diag.Diagnostics, id string, read wait.Read, currentState *types.String) {
+	state, err := wait.ForState(ctx, read, k.States)
+
+	if state != "" {
+		*currentState = types.StringValue(state)
+	}
+
+	if err != nil {
+		diags.AddError(
+			"Error waiting for "+k.Name,
+			"The "+k.Name+" id "+id+" did not become ready: "+err.Error(),
+		)
+	}
+}
+
+// AwaitDeletion waits until the API is done deleting the object id, reading
+// its state with read.
+func (k Kind) AwaitDeletion(ctx context.Context, diags *diag.Diagnostics, id string, read wait.Read) {
+	if _, err := wait.ForDeletion(ctx, read); err != nil {
+		diags.AddError(
+			"Error waiting for "+k.Name+" deletion",
+			"The "+k.Name+" id "+id+" was not deleted: "+err.Error(),
+		)
+	}
+}
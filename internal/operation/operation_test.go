@@ -0,0 +1,105 @@
+package operation_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/upmaru/terraform-provider-instellar/internal/acceptancetest"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+	"github.com/upmaru/terraform-provider-instellar/internal/operation"
+	"github.com/upmaru/terraform-provider-instellar/internal/wait"
+)
+
+var node = operation.Kind{
+	Name:       "node",
+	Attributes: map[string]path.Path{"public_ip": path.Root("public_ip")},
+	States:     wait.States{Ready: []string{"healthy"}, Failed: wait.FailedStates},
+}
+
+func TestStartRefusesReadOnlyClient(t *testing.T) {
+	var diags diag.Diagnostics
+
+	timeout := func(context.Context, time.Duration) (time.Duration, diag.Diagnostics) {
+		t.Fatalf("expected the timeout not to be read")
+		return 0, nil
+	}
+
+	_, cancel := node.Start(context.Background(), acceptancetest.MemoryClient(t, apiclient.Options{ReadOnly: true}), &diags, operation.Delete, timeout, types.StringNull())
+	defer cancel()
+
+	if !diags.HasError() || diags[0].Summary() != "Error deleting node" {
+		t.Errorf("expected a read-only error, got %v", diags)
+	}
+}
+
+func TestStartBoundsContextByTimeout(t *testing.T) {
+	var diags diag.Diagnostics
+
+	timeout := func(_ context.Context, defaultTimeout time.Duration) (time.Duration, diag.Diagnostics) {
+		if defaultTimeout != wait.DefaultTimeout {
+			t.Errorf("expected default timeout %s, got %s", wait.DefaultTimeout, defaultTimeout)
+		}
+
+		return time.Minute, nil
+	}
+
+	ctx, cancel := node.Start(context.Background(), acceptancetest.MemoryClient(t, apiclient.Options{}), &diags, operation.Create, timeout, types.StringValue("acme"))
+	defer cancel()
+
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("expected a deadline within a minute, got %v", deadline)
+	}
+}
+
+func TestFailedReportsFieldAndUnexpectedErrors(t *testing.T) {
+	var diags diag.Diagnostics
+
+	if node.Failed(&diags, operation.Create, nil) || len(diags) > 0 {
+		t.Fatalf("expected no diagnostic without error, got %v", diags)
+	}
+
+	err := fmt.Errorf("status: 422 body: %s", `{"errors":{"public_ip":["is invalid"]}}`)
+
+	if !node.Failed(&diags, operation.Update, err) {
+		t.Fatalf("expected field errors to be reported")
+	}
+
+	attributeDiag, ok := diags[0].(diag.DiagnosticWithPath)
+	if !ok || !attributeDiag.Path().Equal(path.Root("public_ip")) || attributeDiag.Summary() != "Error updating node" {
+		t.Errorf("expected error on public_ip, got %v", diags[0])
+	}
+
+	diags = nil
+
+	if !node.Failed(&diags, operation.Create, errors.New("boom")) || diags[0].Detail() != "Could not create node, unexpected error: boom" {
+		t.Errorf("expected unexpected error, got %v", diags)
+	}
+}
+
+func TestAwaitReadyReportsLastState(t *testing.T) {
+	var diags diag.Diagnostics
+
+	currentState := types.StringValue("created")
+
+	node.AwaitReady(context.Background(), &diags, "1", func(context.Context) (string, error) {
+		return wait.DeletedState, nil
+	}, &currentState)
+
+	if !diags.HasError() || diags[0].Summary() != "Error waiting for node" {
+		t.Fatalf("expected a waiting error, got %v", diags)
+	}
+
+	if currentState.ValueString() != wait.DeletedState {
+		t.Errorf("expected the last state read, got %s", currentState)
+	}
+}
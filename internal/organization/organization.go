@@ -0,0 +1,49 @@
+// Package organization lets resources act on an Instellar organization other
+// than the one selected by the provider.
+package organization
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ResourceAttribute is the organization attribute of resources. Objects
+// cannot move between organizations, changing it replaces the resource.
+func ResourceAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: "Organization owning the resource, defaults to the organization of the provider",
+		Optional:    true,
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.RequiresReplace(),
+		},
+	}
+}
+
+// ImportState imports a resource from its ID, or from organization/ID for
+// resources outside the organization of the provider.
+func ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	organization, id, found := strings.Cut(req.ID, "/")
+
+	if !found {
+		resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+		return
+	}
+
+	if organization == "" || id == "" {
+		resp.Diagnostics.AddError(
+			"Unexpected Import Identifier",
+			"Expected an import identifier of the form id or organization/id, got: "+req.ID,
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("organization"), types.StringValue(organization))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), types.StringValue(id))...)
+}
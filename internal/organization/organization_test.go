@@ -0,0 +1,65 @@
+package organization_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/upmaru/terraform-provider-instellar/internal/organization"
+)
+
+func importState(t *testing.T, id string) (resource.ImportStateResponse, string, string) {
+	t.Helper()
+
+	resourceSchema := schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id":           schema.StringAttribute{Computed: true},
+			"organization": organization.ResourceAttribute(),
+		},
+	}
+
+	resp := resource.ImportStateResponse{
+		State: tfsdk.State{
+			Schema: resourceSchema,
+			Raw:    tftypes.NewValue(resourceSchema.Type().TerraformType(context.Background()), nil),
+		},
+	}
+
+	organization.ImportState(context.Background(), resource.ImportStateRequest{ID: id}, &resp)
+
+	var importedID, importedOrganization types.String
+
+	resp.State.GetAttribute(context.Background(), path.Root("id"), &importedID)
+	resp.State.GetAttribute(context.Background(), path.Root("organization"), &importedOrganization)
+
+	return resp, importedID.ValueString(), importedOrganization.ValueString()
+}
+
+func TestImportState(t *testing.T) {
+	cases := map[string][2]string{
+		"42":        {"42", ""},
+		"upmaru/42": {"42", "upmaru"},
+	}
+
+	for importID, expected := range cases {
+		resp, id, org := importState(t, importID)
+
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected diagnostics for %s: %v", importID, resp.Diagnostics)
+		}
+
+		if id != expected[0] || org != expected[1] {
+			t.Errorf("expected %s imported as %v, got id %q and organization %q", importID, expected, id, org)
+		}
+	}
+
+	if resp, _, _ := importState(t, "upmaru/"); !resp.Diagnostics.HasError() {
+		t.Errorf("expected an error for an import identifier without ID")
+	}
+}
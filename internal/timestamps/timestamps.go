@@ -0,0 +1,106 @@
+// Package timestamps holds the created_at and updated_at attributes of
+// resources, filled from the times the Instellar API reports for an object,
+// and the last_updated attribute they replace.
+package timestamps
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+)
+
+// CreatedAtAttribute is the created_at attribute of resources. It never
+// changes once the object exists.
+func CreatedAtAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: "Time the resource was created at, in RFC 3339 format",
+		Computed:    true,
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.UseStateForUnknown(),
+		},
+	}
+}
+
+// UpdatedAtAttribute is the updated_at attribute of resources.
+func UpdatedAtAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: "Time the resource was last updated at, in RFC 3339 format",
+		Computed:    true,
+	}
+}
+
+// LastUpdatedAttribute is the last_updated attribute of resources, which
+// holds the same value as updated_at when the API reports it.
+func LastUpdatedAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Description:        "Time the resource was last updated at, the same as updated_at when the API reports it",
+		Computed:           true,
+		DeprecationMessage: "Use updated_at instead, last_updated will be removed in a future version.",
+	}
+}
+
+// Set sets created_at, updated_at and last_updated to the times the API
+// reported for an object it just created or updated. last_updated is null
+// when the API does not report updated_at, as Terraform cannot know the time
+// in the plan.
+func Set(ctx context.Context, reported apiclient.Reported, createdAt, updatedAt, lastUpdated *types.String) {
+	if values(ctx, reported, createdAt, updatedAt) {
+		*lastUpdated = *updatedAt
+		return
+	}
+
+	if reported.UpdatedAt == "" {
+		tflog.Warn(ctx, "Instellar API did not report when the object was last updated, leaving last_updated empty")
+	}
+
+	if lastUpdated.IsUnknown() {
+		*lastUpdated = types.StringNull()
+	}
+}
+
+// Refresh sets created_at, updated_at and last_updated to the times the API
+// reported for an object it was read from. last_updated keeps its value when
+// the API does not report updated_at.
+func Refresh(ctx context.Context, reported apiclient.Reported, createdAt, updatedAt, lastUpdated *types.String) {
+	values(ctx, reported, createdAt, updatedAt)
+
+	if !updatedAt.IsNull() && !updatedAt.IsUnknown() {
+		*lastUpdated = *updatedAt
+	}
+}
+
+// values sets createdAt and updatedAt to the times in reported. Times that
+// cannot be parsed are logged and leave the values as they were, or null
+// when they were unknown. It returns whether updated_at was reported.
+func values(ctx context.Context, reported apiclient.Reported, createdAt, updatedAt *types.String) bool {
+	t, err := reported.Timestamps()
+	if err != nil {
+		tflog.Warn(ctx, "Instellar API reported timestamps that could not be read", map[string]any{"error": err.Error()})
+
+		for _, value := range []*types.String{createdAt, updatedAt} {
+			if value.IsUnknown() {
+				*value = types.StringNull()
+			}
+		}
+
+		return false
+	}
+
+	*createdAt, *updatedAt = value(t.CreatedAt), value(t.UpdatedAt)
+
+	return !t.UpdatedAt.IsZero()
+}
+
+func value(t time.Time) types.String {
+	if t.IsZero() {
+		return types.StringNull()
+	}
+
+	return types.StringValue(t.Format(time.RFC3339))
+}
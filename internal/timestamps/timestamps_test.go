@@ -0,0 +1,71 @@
+package timestamps
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+)
+
+func TestSetUsesReportedTimes(t *testing.T) {
+	createdAt, updatedAt, lastUpdated := types.StringUnknown(), types.StringUnknown(), types.StringUnknown()
+
+	Set(context.Background(), apiclient.Reported{InsertedAt: "2024-01-02T03:04:05", UpdatedAt: "2024-01-03T00:00:00Z"}, &createdAt, &updatedAt, &lastUpdated)
+
+	if createdAt.ValueString() != "2024-01-02T03:04:05Z" || updatedAt.ValueString() != "2024-01-03T00:00:00Z" {
+		t.Errorf("unexpected timestamps %s, %s", createdAt, updatedAt)
+	}
+
+	if !lastUpdated.Equal(updatedAt) {
+		t.Errorf("expected last_updated to be updated_at, got %s", lastUpdated)
+	}
+}
+
+func TestSetLeavesUnreportedTimesNull(t *testing.T) {
+	for name, reported := range map[string]apiclient.Reported{
+		"missing":    {},
+		"unreadable": {UpdatedAt: "yesterday"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			createdAt, updatedAt, lastUpdated := types.StringUnknown(), types.StringUnknown(), types.StringUnknown()
+
+			Set(context.Background(), reported, &createdAt, &updatedAt, &lastUpdated)
+
+			if !createdAt.IsNull() || !updatedAt.IsNull() {
+				t.Errorf("expected unreported timestamps to be null, got %s, %s", createdAt, updatedAt)
+			}
+
+			if !lastUpdated.IsNull() {
+				t.Errorf("expected last_updated to be null, got %s", lastUpdated)
+			}
+		})
+	}
+}
+
+func TestRefreshKeepsValuesOfUnreadableTimes(t *testing.T) {
+	createdAt := types.StringValue("2024-01-02T03:04:05Z")
+	updatedAt := types.StringValue("2024-01-03T00:00:00Z")
+	lastUpdated := types.StringValue("Wednesday, 03-Jan-24 00:00:00 UTC")
+
+	Refresh(context.Background(), apiclient.Reported{UpdatedAt: "yesterday"}, &createdAt, &updatedAt, &lastUpdated)
+
+	if createdAt.ValueString() != "2024-01-02T03:04:05Z" || updatedAt.ValueString() != "2024-01-03T00:00:00Z" {
+		t.Errorf("expected timestamps to be kept, got %s, %s", createdAt, updatedAt)
+	}
+
+	if !lastUpdated.Equal(updatedAt) {
+		t.Errorf("expected last_updated to be updated_at, got %s", lastUpdated)
+	}
+}
+
+func TestRefreshKeepsLastUpdatedWithoutUpdatedAt(t *testing.T) {
+	createdAt, updatedAt := types.StringNull(), types.StringNull()
+	lastUpdated := types.StringValue("Wednesday, 03-Jan-24 00:00:00 UTC")
+
+	Refresh(context.Background(), apiclient.Reported{}, &createdAt, &updatedAt, &lastUpdated)
+
+	if lastUpdated.ValueString() != "Wednesday, 03-Jan-24 00:00:00 UTC" {
+		t.Errorf("expected last_updated to be kept, got %s", lastUpdated)
+	}
+}
@@ -0,0 +1,155 @@
+// Package wait polls Instellar objects until they reach a given state.
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/upmaru/terraform-provider-instellar/internal/apiclient"
+)
+
+// DefaultTimeout bounds an operation when the timeouts block of a resource
+// leaves it out.
+const DefaultTimeout = 20 * time.Minute
+
+// DeletedState is the state of an object the API is done deleting but still
+// returns.
+const DeletedState = "deleted"
+
+// FailedStates are the states no object leaves on its own. The API reports
+// no failure state, an object it deleted is the only one that never becomes
+// ready.
+var FailedStates = []string{DeletedState}
+
+const (
+	initialInterval = time.Second
+	maxInterval     = 10 * time.Second
+)
+
+var (
+	// ErrTimeout is returned when an object did not reach the expected state
+	// before the deadline of the operation.
+	ErrTimeout = errors.New("timed out")
+	// ErrCanceled is returned when the operation was canceled before the
+	// object reached the expected state.
+	ErrCanceled = errors.New("canceled")
+	// ErrFailed is returned when an object reached a state it does not leave
+	// on its own.
+	ErrFailed = errors.New("failed")
+)
+
+// States are the states ForState tells apart.
+type States struct {
+	// Ready are the states an object is usable in.
+	Ready []string
+	// Failed are the states an object does not leave on its own.
+	Failed []string
+}
+
+// Read returns the current state of the object being waited for.
+type Read func(ctx context.Context) (string, error)
+
+// ForState calls read until the state it returns is one of the ready
+// states, waiting longer between each call. It returns the last state read,
+// gives up as soon as a failed state is read, and once ctx is done, with an
+// error mentioning that state.
+func ForState(ctx context.Context, read Read, states States) (string, error) {
+	description := "state " + strings.Join(states.Ready, " or ")
+
+	return poll(ctx, description, func(ctx context.Context) (string, bool, error) {
+		state, err := read(ctx)
+		if err != nil {
+			return "", false, err
+		}
+
+		if contains(states.Failed, state) {
+			return state, false, fmt.Errorf("%w waiting for %s, reached state %q", ErrFailed, description, state)
+		}
+
+		return state, contains(states.Ready, state), nil
+	})
+}
+
+func contains(states []string, state string) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ForDeletion calls read until the API no longer finds the object or
+// returns it in DeletedState. It returns the last state read, and gives up
+// once ctx is done with an error mentioning that state.
+func ForDeletion(ctx context.Context, read Read) (string, error) {
+	return poll(ctx, "deletion", func(ctx context.Context) (string, bool, error) {
+		state, err := read(ctx)
+		if apiclient.IsNotFound(err) {
+			return "", true, nil
+		}
+
+		if err != nil {
+			return "", false, err
+		}
+
+		return state, state == DeletedState, nil
+	})
+}
+
+// poll calls check until it reports done, and returns the last state it
+// read. description names what is waited for in the timeout error.
+func poll(ctx context.Context, description string, check func(ctx context.Context) (string, bool, error)) (string, error) {
+	interval := initialInterval
+	state := ""
+
+	for {
+		current, done, err := check(ctx)
+
+		if current != "" {
+			state = current
+		}
+
+		if err != nil && ctx.Err() == nil {
+			return state, err
+		}
+
+		if err == nil {
+			if done {
+				return state, nil
+			}
+
+			tflog.Debug(ctx, "Waiting for Instellar object", map[string]any{
+				"current_state": state,
+				"waiting_for":   description,
+			})
+		}
+
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			reason := ErrTimeout
+
+			if errors.Is(ctx.Err(), context.Canceled) {
+				reason = ErrCanceled
+			}
+
+			return state, fmt.Errorf("%w waiting for %s, last observed state %q", reason, description, state)
+		case <-timer.C:
+		}
+
+		interval *= 2
+
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
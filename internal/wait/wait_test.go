@@ -0,0 +1,139 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestForStateReturnsOnceTargetIsReached(t *testing.T) {
+	states := []string{"created", "syncing", "healthy"}
+	reads := 0
+
+	state, err := ForState(context.Background(), func(context.Context) (string, error) {
+		state := states[reads]
+		reads++
+
+		return state, nil
+	}, States{Ready: []string{"healthy", "active"}})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if state != "healthy" || reads != 3 {
+		t.Errorf("expected healthy after 3 reads, got %s after %d", state, reads)
+	}
+}
+
+func TestForStateReportsLastStateOnTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	state, err := ForState(ctx, func(context.Context) (string, error) {
+		return "syncing", nil
+	}, States{Ready: []string{"healthy"}})
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	if state != "syncing" || err.Error() != `timed out waiting for state healthy, last observed state "syncing"` {
+		t.Errorf("unexpected state %s and error %s", state, err)
+	}
+}
+
+func TestForStateReportsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, err := ForState(ctx, func(context.Context) (string, error) {
+		cancel()
+
+		return "syncing", nil
+	}, States{Ready: []string{"healthy"}})
+
+	if !errors.Is(err, ErrCanceled) || errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+
+	if err.Error() != `canceled waiting for state healthy, last observed state "syncing"` {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+func TestForStateStopsAtFailedState(t *testing.T) {
+	states := []string{"connecting", "unreachable", "healthy"}
+	reads := 0
+
+	state, err := ForState(context.Background(), func(context.Context) (string, error) {
+		state := states[reads]
+		reads++
+
+		return state, nil
+	}, States{Ready: []string{"healthy"}, Failed: []string{"unreachable"}})
+
+	if !errors.Is(err, ErrFailed) {
+		t.Fatalf("expected failure, got %v", err)
+	}
+
+	if state != "unreachable" || reads != 2 || err.Error() != `failed waiting for state healthy, reached state "unreachable"` {
+		t.Errorf("expected to stop at unreachable after 2 reads, got %s after %d: %s", state, reads, err)
+	}
+}
+
+func TestForStateReturnsReadErrors(t *testing.T) {
+	expected := errors.New("status: 500 body: boom")
+
+	_, err := ForState(context.Background(), func(context.Context) (string, error) {
+		return "", expected
+	}, States{Ready: []string{"healthy"}})
+
+	if !errors.Is(err, expected) {
+		t.Errorf("expected read error, got %v", err)
+	}
+}
+
+func TestForDeletionReturnsOnceObjectIsGone(t *testing.T) {
+	reads := 0
+
+	state, err := ForDeletion(context.Background(), func(context.Context) (string, error) {
+		reads++
+
+		if reads == 1 {
+			return "deleting", nil
+		}
+
+		return "", fmt.Errorf("status: 404 body: %s", `{"errors":{"detail":"Not Found"}}`)
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if state != "deleting" || reads != 2 {
+		t.Errorf("expected deletion after 2 reads with last state deleting, got %s after %d", state, reads)
+	}
+}
+
+func TestForDeletionAcceptsDeletedState(t *testing.T) {
+	if _, err := ForDeletion(context.Background(), func(context.Context) (string, error) {
+		return DeletedState, nil
+	}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestForDeletionReportsStuckDeletion(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := ForDeletion(ctx, func(context.Context) (string, error) {
+		return "deleting", nil
+	})
+
+	if !errors.Is(err, ErrTimeout) || err.Error() != `timed out waiting for deletion, last observed state "deleting"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
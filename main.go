@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"os"
+	"strconv"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/upmaru/terraform-provider-instellar/instellar"
+	"github.com/upmaru/terraform-provider-instellar/internal/doctor"
+	"github.com/upmaru/terraform-provider-instellar/internal/generate"
 )
 
 // Run "go generate" to format example terraform files and generate the docs for the registry/website
@@ -18,9 +23,42 @@ import (
 // can be customized.
 //go:generate go run github.com/hashicorp/terraform-plugin-docs/cmd/tfplugindocs
 
+var (
+	// version is set by the goreleaser configuration to the released version
+	// of the provider.
+	version string = "dev"
+
+	// protocolVersion is the plugin protocol served by default. Registry
+	// releases serve 6, the protocol terraform-registry-manifest.json
+	// declares. Build with -ldflags "-X main.protocolVersion=5" for Terraform
+	// releases and wrappers that only speak protocol 5, Terraform never
+	// passes -protocol.
+	protocolVersion string = "6"
+)
+
 func main() {
-	err := providerserver.Serve(context.Background(), instellar.New, providerserver.ServeOpts{
-		Address: "registry.terraform.io/upmaru/instellar",
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "doctor":
+			os.Exit(doctor.Main(context.Background(), os.Args[2:], version, os.Stdout))
+		case "generate":
+			os.Exit(generate.Main(context.Background(), os.Args[2:], version, os.Stdout, os.Stderr))
+		}
+	}
+
+	defaultProtocol, err := strconv.Atoi(protocolVersion)
+	if err != nil {
+		log.Fatalf("invalid protocol version %q: %s", protocolVersion, err)
+	}
+
+	var protocol int
+
+	flag.IntVar(&protocol, "protocol", defaultProtocol, "plugin protocol version to serve, 5 or 6")
+	flag.Parse()
+
+	err = providerserver.Serve(context.Background(), instellar.New(version), providerserver.ServeOpts{
+		Address:         "registry.terraform.io/upmaru/instellar",
+		ProtocolVersion: protocol,
 	})
 
 	if err != nil {